@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/application/usecase"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/config"
+	database "github.com/WaveCE29/product_order_system/internal/infrastructure/db"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/persistence"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+const apiKeyUsage = "usage: server apikey create -name NAME -subject SUBJECT -scopes SCOPES [-ttl DURATION] | list | revoke ID"
+
+// runAPIKey implements the "apikey" subcommand. "create" prints the new key,
+// which cannot be shown again; "list" shows every key without its secret;
+// "revoke" disables a key for good.
+func runAPIKey(cfg *config.Config, logger logger.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+
+	db, err := database.OpenDatabase(cfg.Database.Path, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(persistence.NewAPIKeyRepository(db.DB), logger)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		var req input.CreateAPIKeyRequest
+		var scopes string
+		flags.StringVar(&req.Name, "name", "", "what the key is for")
+		flags.StringVar(&req.Subject, "subject", "", "the user the key acts as")
+		flags.StringVar(&scopes, "scopes", "", "comma separated scopes: "+strings.Join(entity.Scopes, ", "))
+		flags.DurationVar(&req.TTL, "ttl", 0, "how long the key is valid; 0 never expires")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() > 0 {
+			return errors.New(apiKeyUsage)
+		}
+		req.Scopes = entity.ParseScopes(scopes)
+
+		key, secret, err := apiKeyUseCase.CreateAPIKey(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created API key %d for %s with scopes %s\n", key.ID, key.Subject, strings.Join(key.Scopes, " "))
+		fmt.Println("Store this key now; it cannot be shown again:")
+		fmt.Println(secret)
+
+	case "list":
+		keys, err := apiKeyUseCase.ListAPIKeys(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSUBJECT\tPREFIX\tSCOPES\tSTATUS\tCREATED AT")
+		for _, k := range keys {
+			status := "active"
+			switch {
+			case k.RevokedAt != nil:
+				status = "revoked " + k.RevokedAt.Format("2006-01-02 15:04:05")
+			case !k.Active(now):
+				status = "expired " + k.ExpiresAt.Format("2006-01-02 15:04:05")
+			case k.ExpiresAt != nil:
+				status = "expires " + k.ExpiresAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Subject, k.Prefix, strings.Join(k.Scopes, " "), status, k.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(apiKeyUsage)
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id < 1 {
+			return fmt.Errorf("id must be a positive integer")
+		}
+		if err := apiKeyUseCase.RevokeAPIKey(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked API key %d\n", id)
+
+	default:
+		return errors.New(apiKeyUsage)
+	}
+
+	return nil
+}
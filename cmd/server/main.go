@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,10 +9,18 @@ import (
 	"syscall"
 
 	"github.com/WaveCE29/product_order_system/internal/adapter/http/handler"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/middleware"
 	"github.com/WaveCE29/product_order_system/internal/adapter/http/router"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/validation"
+	"github.com/WaveCE29/product_order_system/internal/adapter/worker"
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
 	"github.com/WaveCE29/product_order_system/internal/application/usecase"
+	"github.com/WaveCE29/product_order_system/internal/domain/allocation"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/auth"
 	"github.com/WaveCE29/product_order_system/internal/infrastructure/config"
 	database "github.com/WaveCE29/product_order_system/internal/infrastructure/db"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/notification"
 	"github.com/WaveCE29/product_order_system/internal/infrastructure/persistence"
 	"github.com/WaveCE29/product_order_system/pkg/logger"
 
@@ -31,8 +40,46 @@ func main() {
 		log.Fatal("Failed to initialize logger:", err)
 	}
 
+	// Schema management subcommands
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(config, logger, os.Args[2:]); err != nil {
+			logger.Error("Migration command failed", "error", err)
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKey(config, logger, os.Args[2:]); err != nil {
+			logger.Error("API key command failed", "error", err)
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		if err := runRole(config, logger, os.Args[2:]); err != nil {
+			logger.Error("Role command failed", "error", err)
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "stock" {
+		if err := runStock(config, logger, os.Args[2:]); err != nil {
+			logger.Error("Stock command failed", "error", err)
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Initialize database
-	db, err := database.NewDatabase(config.Database.Path, logger)
+	var db *database.Database
+	if config.Database.AutoMigrate {
+		db, err = database.NewDatabase(config.Database.Path, logger)
+	} else {
+		db, err = database.OpenDatabase(config.Database.Path, logger)
+	}
 	if err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		log.Fatal(err)
@@ -46,29 +93,95 @@ func main() {
 
 	productRepo := persistence.NewProductRepository(db.DB)
 	orderRepo := persistence.NewOrderRepository(db.DB)
+	idempotencyRepo := persistence.NewIdempotencyRepository(db.DB)
+	stockMovementRepo := persistence.NewStockMovementRepository(db.DB)
+	warehouseRepo := persistence.NewWarehouseRepository(db.DB)
+	roleRepo := persistence.NewRoleRepository(db.DB)
+	txManager := persistence.NewTransactionManager(db.DB)
 
-	productUseCase := usecase.NewProductUseCase(productRepo, logger)
-	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, logger)
+	allocator, err := allocation.NewStrategy(config.Allocation.Strategy)
+	if err != nil {
+		logger.Error("Invalid allocation strategy", "error", err)
+		log.Fatal(err)
+	}
+
+	// Low-stock alerts go to the webhook when one is configured, otherwise to the log
+	var notifier output.StockAlertNotifier
+	if config.Alerts.WebhookURL != "" {
+		notifier = notification.NewWebhookNotifier(config.Alerts.WebhookURL, config.Alerts.WebhookSecret, config.Alerts.WebhookTimeout)
+		logger.Info("Low-stock alerts delivered by webhook", "url", config.Alerts.WebhookURL)
+	} else {
+		notifier = notification.NewLogNotifier(logger)
+	}
+
+	// Alerts are delivered in the background and drained on shutdown
+	alerts := notification.NewAsyncNotifier(notifier, logger)
+
+	// Every use case checks the caller's permissions against their roles
+	policy := authz.NewPolicy(roleRepo, logger)
 
-	h := handler.NewHandler(productUseCase, orderUseCase, logger)
+	// Stock added through products or stock changes is offered to backorders by the order use case
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, warehouseRepo, txManager, allocator, config.Reservation.TTL, alerts, policy, logger)
+	productUseCase := usecase.NewProductUseCase(productRepo, txManager, policy, logger)
+	stockUseCase := usecase.NewStockUseCase(productRepo, warehouseRepo, stockMovementRepo, txManager, orderUseCase, alerts, policy, logger)
+	warehouseUseCase := usecase.NewWarehouseUseCase(warehouseRepo, policy, logger)
+
+	h := handler.NewHandler(productUseCase, orderUseCase, stockUseCase, warehouseUseCase, validation.New(config.Validation.Strict), logger)
 
 	app := fiber.New(fiber.Config{
-		AppName: "Product Order System",
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
+		AppName:      "Product Order System",
+		ErrorHandler: handler.ErrorHandler(logger),
+	})
 
-			logger.Error("Request error", "error", err, "path", c.Path(), "method", c.Method())
+	mw := router.Middleware{
+		Idempotency: middleware.Idempotency(middleware.IdempotencyConfig{
+			Store:      idempotencyRepo,
+			Logger:     logger,
+			TTL:        config.Idempotency.TTL,
+			RequireKey: config.Idempotency.RequireKey,
+		}),
+	}
 
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
+	// API keys are always accepted; JWTs only with a key set to verify them
+	if config.Auth.Enabled {
+		authConfig := middleware.AuthConfig{
+			APIKeys: usecase.NewAPIKeyUseCase(persistence.NewAPIKeyRepository(db.DB), logger),
+			Logger:  logger,
+		}
+		if config.Auth.JWKSFile != "" {
+			keys, err := auth.LoadKeySet(config.Auth.JWKSFile)
+			if err != nil {
+				logger.Error("Failed to load JWT key set", "error", err)
+				log.Fatal(err)
+			}
+			authConfig.Tokens = auth.NewJWTVerifier(auth.VerifierConfig{
+				Keys:     keys,
+				Issuer:   config.Auth.JWTIssuer,
+				Audience: config.Auth.JWTAudience,
 			})
-		},
-	})
+			logger.Info("JWT authentication enabled", "key_set", config.Auth.JWKSFile)
+		}
+		mw.Authenticate = middleware.Authenticate(authConfig)
+		mw.RequireScope = middleware.RequireScope
+	} else {
+		logger.Warn("Authentication disabled; every route is open and acts for the system")
+	}
 
-	router.SetupRoutes(app, h, logger)
+	router.SetupRoutes(app, h, mw, logger)
+
+	// Release lapsed stock reservations in the background until shutdown
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	sweeperDone := make(chan struct{})
+	if config.Reservation.SweepInterval > 0 {
+		sweeper := worker.NewReservationSweeper(orderUseCase, config.Reservation.SweepInterval, logger)
+		go func() {
+			defer close(sweeperDone)
+			sweeper.Run(sweeperCtx)
+		}()
+	} else {
+		logger.Warn("Reservation sweeper disabled", "interval", config.Reservation.SweepInterval)
+		close(sweeperDone)
+	}
 
 	go func() {
 		address := fmt.Sprintf("%s:%s", config.Server.Host, config.Server.Port)
@@ -91,6 +204,15 @@ func main() {
 		logger.Error("Server forced to shutdown", "error", err)
 	}
 
+	stopSweeper()
+	<-sweeperDone
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), config.Alerts.WebhookTimeout)
+	if err := alerts.Drain(drainCtx); err != nil {
+		logger.Error("Low-stock alerts still in flight at shutdown were dropped", "error", err)
+	}
+	cancelDrain()
+
 	logger.Info("Server shutdown completed")
 
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/usecase"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/config"
+	database "github.com/WaveCE29/product_order_system/internal/infrastructure/db"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/persistence"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+const roleUsage = "usage: server role list | assign SUBJECT ROLE | unassign SUBJECT ROLE"
+
+// runRole implements the "role" subcommand. "list" shows every role with its
+// permissions and every subject's assigned roles; "assign" and "unassign"
+// change which roles a subject holds. Subjects without a role are customers.
+func runRole(cfg *config.Config, logger logger.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(roleUsage)
+	}
+
+	db, err := database.OpenDatabase(cfg.Database.Path, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	roleRepo := persistence.NewRoleRepository(db.DB)
+	roleUseCase := usecase.NewRoleUseCase(roleRepo, authz.NewPolicy(roleRepo, logger), logger)
+	ctx := authz.AsSystem(context.Background())
+
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return errors.New(roleUsage)
+		}
+		roles, err := roleUseCase.ListRoles(ctx)
+		if err != nil {
+			return err
+		}
+		assignments, err := roleUseCase.ListRoleAssignments(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ROLE\tDESCRIPTION\tPERMISSIONS")
+		for _, r := range roles {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Description, strings.Join(r.Permissions, " "))
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "SUBJECT\tROLE\tASSIGNED AT")
+		for _, a := range assignments {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", a.Subject, a.Role, a.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+		return w.Flush()
+
+	case "assign":
+		if len(args) != 3 {
+			return errors.New(roleUsage)
+		}
+		if err := roleUseCase.AssignRole(ctx, args[1], args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("Assigned role %s to %s\n", args[2], args[1])
+
+	case "unassign":
+		if len(args) != 3 {
+			return errors.New(roleUsage)
+		}
+		if err := roleUseCase.UnassignRole(ctx, args[1], args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("Unassigned role %s from %s\n", args[2], args[1])
+
+	default:
+		return errors.New(roleUsage)
+	}
+
+	return nil
+}
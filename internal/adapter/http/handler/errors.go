@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// problemTypeBase prefixes a domain error code to form its problem type.
+// Errors without a code use "about:blank", whose title is the status text.
+const problemTypeBase = "/problems/"
+
+// Problem is an RFC 7807 problem details object. RequestID, Code and Errors
+// are extension members: the ID assigned by the requestid middleware, the
+// domain error code and, for validation failures, every invalid field.
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Code      string                 `json:"code,omitempty"`
+	Errors    []domainerr.FieldError `json:"errors,omitempty"`
+}
+
+// newProblem maps err onto problem details for the request in c. Domain
+// errors keep their message as the detail; anything unclassified is reported
+// as an internal error without leaking details.
+func newProblem(c *fiber.Ctx, err error) Problem {
+	problem := Problem{
+		Type:     "about:blank",
+		Status:   fiber.StatusInternalServerError,
+		Instance: c.Path(),
+	}
+	if id, ok := c.Locals(requestid.ConfigDefault.ContextKey).(string); ok {
+		problem.RequestID = id
+	}
+
+	var domainErr *domainerr.Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &domainErr):
+		problem.Type = problemTypeBase + domainErr.Code
+		problem.Title = capitalize(domainErr.Summary())
+		problem.Status = statusForKind(domainErr.Kind)
+		problem.Detail = domainErr.Message
+		problem.Code = domainErr.Code
+		problem.Errors = domainErr.Fields
+	case errors.As(err, &fiberErr):
+		problem.Status = fiberErr.Code
+		problem.Detail = fiberErr.Message
+	}
+
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	// A detail that only repeats the title adds nothing
+	if strings.EqualFold(problem.Detail, problem.Title) {
+		problem.Detail = ""
+	}
+	return problem
+}
+
+func statusForKind(kind domainerr.Kind) int {
+	switch kind {
+	case domainerr.KindInvalid:
+		return fiber.StatusBadRequest
+	case domainerr.KindNotFound:
+		return fiber.StatusNotFound
+	case domainerr.KindConflict:
+		return fiber.StatusConflict
+	case domainerr.KindUnprocessable:
+		return fiber.StatusUnprocessableEntity
+	case domainerr.KindUnavailable:
+		return fiber.StatusServiceUnavailable
+	case domainerr.KindUnauthenticated:
+		return fiber.StatusUnauthorized
+	case domainerr.KindForbidden:
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func writeProblem(c *fiber.Ctx, err error) error {
+	problem := newProblem(c, err)
+	return c.Status(problem.Status).JSON(problem, ProblemContentType)
+}
+
+func (h *Handler) respondError(c *fiber.Ctx, err error) error {
+	return writeProblem(c, err)
+}
+
+// ErrorHandler renders errors that escape handlers and middleware, including
+// recovered panics and unknown routes, with the same mapping the handlers use.
+func ErrorHandler(logger logger.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		logger.Error("Request error", "error", err, "path", c.Path(), "method", c.Method(), "request_id", c.Locals(requestid.ConfigDefault.ContextKey))
+
+		return writeProblem(c, err)
+	}
+}
@@ -1,56 +1,114 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
+	"time"
 
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/middleware"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/validation"
 	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
 	"github.com/WaveCE29/product_order_system/pkg/logger"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
+var errInvalidBody = fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+
 type Handler struct {
-	productUseCase input.ProductUseCase
-	orderUseCase   input.OrderUseCase
-	logger         logger.Logger
+	productUseCase   input.ProductUseCase
+	orderUseCase     input.OrderUseCase
+	stockUseCase     input.StockUseCase
+	warehouseUseCase input.WarehouseUseCase
+	validator        *validation.Validator
+	logger           logger.Logger
 }
 
-func NewHandler(productUseCase input.ProductUseCase, orderUseCase input.OrderUseCase, logger logger.Logger) *Handler {
+func NewHandler(productUseCase input.ProductUseCase, orderUseCase input.OrderUseCase, stockUseCase input.StockUseCase, warehouseUseCase input.WarehouseUseCase, validator *validation.Validator, logger logger.Logger) *Handler {
 	return &Handler{
-		productUseCase: productUseCase,
-		orderUseCase:   orderUseCase,
-		logger:         logger,
+		productUseCase:   productUseCase,
+		orderUseCase:     orderUseCase,
+		stockUseCase:     stockUseCase,
+		warehouseUseCase: warehouseUseCase,
+		validator:        validator,
+		logger:           logger,
 	}
 }
 
-// Product handlers
-func (h *Handler) CreateProduct(c *fiber.Ctx) error {
-	var req input.CreateProductRequest
-	if err := c.BodyParser(&req); err != nil {
+// bind parses the request body into req and validates it; see decode.
+func (h *Handler) bind(c *fiber.Ctx, req interface{}) error {
+	errs, err := h.decode(c, req)
+	if err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+// decode parses the request body into req and returns the violations of its
+// validate tags and, in strict mode, its unknown fields. Handlers add the
+// checks the tags cannot express before reporting them all with Err. A body
+// that cannot be parsed is an error of its own.
+func (h *Handler) decode(c *fiber.Ctx, req interface{}) (validation.Errors, error) {
+	if err := h.parse(c, req); err != nil {
+		return nil, err
+	}
+	return h.check(c, req), nil
+}
+
+// parse parses the request body into req without validating it, for handlers
+// that fill in fields before decode's checks run.
+func (h *Handler) parse(c *fiber.Ctx, req interface{}) error {
+	if err := c.BodyParser(req); err != nil {
 		h.logger.Error("Failed to parse request body", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return domainerr.Invalid(typeErr.Field, "type", fmt.Sprintf("%s must be a JSON %s", typeErr.Field, jsonType(typeErr.Type.Kind())))
+		}
+		return errInvalidBody
 	}
+	return nil
+}
 
-	if req.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product name is required",
-		})
+// check returns the violations decode reports for a parsed req.
+func (h *Handler) check(c *fiber.Ctx, req interface{}) validation.Errors {
+	errs := h.validator.UnknownFields(c.Body(), req)
+	return append(errs, h.validator.Struct(req)...)
+}
+
+// jsonType names the JSON type a Go kind decodes from.
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return "number"
 	}
+}
 
-	if req.Stock < 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Stock must be non-negative",
-		})
+// Product handlers
+func (h *Handler) CreateProduct(c *fiber.Ctx) error {
+	var req input.CreateProductRequest
+	if err := h.bind(c, &req); err != nil {
+		return h.respondError(c, err)
 	}
 
-	product, err := h.productUseCase.CreateProduct(c.Context(), req)
+	product, err := h.productUseCase.CreateProduct(c.UserContext(), req)
 	if err != nil {
 		h.logger.Error("Failed to create product", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create product",
-		})
+		return h.respondError(c, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -60,20 +118,72 @@ func (h *Handler) CreateProduct(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetProduct(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := parseID(c, "Invalid product ID")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return h.respondError(c, err)
 	}
 
-	product, err := h.productUseCase.GetProduct(c.Context(), id)
+	product, err := h.productUseCase.GetProduct(c.UserContext(), id)
 	if err != nil {
 		h.logger.Error("Failed to get product", "id", id, "error", err)
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product not found",
-		})
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Product retrieved successfully",
+		"data":    product,
+	})
+}
+
+// SearchProducts runs a full-text search over product name, SKU, category and
+// description.
+func (h *Handler) SearchProducts(c *fiber.Ctx) error {
+	req := input.SearchProductsRequest{Query: c.Query("q")}
+	if req.Query == "" {
+		return h.respondError(c, domainerr.Invalid("q", "required", "Search query is required"))
+	}
+
+	limit, err := parseLimit(c)
+	if err != nil {
+		return h.respondError(c, err)
+	}
+	req.Limit = limit
+
+	if offset := c.Query("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return h.respondError(c, domainerr.Invalid("offset", "min", "offset must be a non-negative integer"))
+		}
+		req.Offset = n
+	}
+
+	results, err := h.productUseCase.SearchProducts(c.UserContext(), req)
+	if err != nil {
+		h.logger.Error("Failed to search products", "query", req.Query, "error", err)
+		return h.respondError(c, err)
+	}
+
+	if results == nil {
+		results = []*entity.ProductSearchResult{}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Products retrieved successfully",
+		"data":    results,
+		"count":   len(results),
+	})
+}
+
+func (h *Handler) GetProductBySKU(c *fiber.Ctx) error {
+	sku := c.Params("sku")
+	if !entity.ValidSKU(sku) {
+		return h.respondError(c, domainerr.Invalid("sku", "invalid", "Invalid SKU"))
+	}
+
+	product, err := h.productUseCase.GetProductBySKU(c.UserContext(), sku)
+	if err != nil {
+		h.logger.Error("Failed to get product by SKU", "sku", sku, "error", err)
+		return h.respondError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -83,74 +193,420 @@ func (h *Handler) GetProduct(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetAllProducts(c *fiber.Ctx) error {
-	products, err := h.productUseCase.GetAllProduct(c.Context())
+	page, err := parsePageRequest(c)
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	products, nextCursor, err := h.productUseCase.GetAllProduct(c.UserContext(), page)
 	if err != nil {
 		h.logger.Error("Failed to get products", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve products",
-		})
+		return h.respondError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Products retrieved successfully",
-		"data":    products,
-		"count":   len(products),
+		"message":     "Products retrieved successfully",
+		"data":        products,
+		"count":       len(products),
+		"next_cursor": nextCursorValue(nextCursor),
 	})
 }
 
-// Order handlers
-func (h *Handler) CreateOrder(c *fiber.Ctx) error {
-	var req input.CreateOrderRequest
-	if err := c.BodyParser(&req); err != nil {
+// ListLowStockProducts lists products at or below their reorder threshold.
+func (h *Handler) ListLowStockProducts(c *fiber.Ctx) error {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	products, nextCursor, err := h.productUseCase.ListLowStockProducts(c.UserContext(), page)
+	if err != nil {
+		h.logger.Error("Failed to list low-stock products", "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":     "Low-stock products retrieved successfully",
+		"data":        products,
+		"count":       len(products),
+		"next_cursor": nextCursorValue(nextCursor),
+	})
+}
+
+func (h *Handler) UpdateProduct(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	var req input.UpdateProductRequest
+	errs, err := h.decode(c, &req)
+	if err != nil {
+		return h.respondError(c, err)
+	}
+	checkStockNotSet(c.Body(), &errs)
+	if err := errs.Err(); err != nil {
+		return h.respondError(c, err)
+	}
+
+	product, err := h.productUseCase.UpdateProduct(c.UserContext(), id, req)
+	if err != nil {
+		h.logger.Error("Failed to update product", "id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Product updated successfully",
+		"data":    product,
+	})
+}
+
+// patchableProductFields lists the fields a merge patch may set, and whether
+// each is optional and so may be removed with null.
+var patchableProductFields = map[string]bool{
+	"sku":                         true,
+	"name":                        false,
+	"description":                 true,
+	"category":                    true,
+	"barcode":                     true,
+	"price":                       false,
+	"currency":                    false,
+	"reorder_threshold":           true,
+	"allow_backorder":             false,
+	"max_backorder_depth":         true,
+	"min_order_quantity":          true,
+	"max_order_quantity":          true,
+	"order_quantity_step":         true,
+	"max_per_user":                true,
+	"max_per_user_window_seconds": true,
+}
+
+// PatchProduct applies a JSON merge patch (RFC 7396) to a product.
+func (h *Handler) PatchProduct(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &fields); err != nil {
 		h.logger.Error("Failed to parse request body", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return h.respondError(c, errInvalidBody)
 	}
 
-	// Generate idempotency key if not provided
-	if req.IdempotencyKey == "" {
-		req.IdempotencyKey = uuid.New().String()
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
 	}
+	sort.Strings(names)
 
-	// Validate request
-	if req.ProductID <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Valid product ID is required",
-		})
+	// Merge patches are always strict: an unknown member is more likely a typo
+	// than something to ignore
+	var (
+		errs    validation.Errors
+		cleared []string
+	)
+	for _, field := range names {
+		optional, ok := patchableProductFields[field]
+		if field == "stock" {
+			continue
+		}
+		if !ok {
+			errs.Add(field, "unknown", fmt.Sprintf("Unknown field %q", field))
+			continue
+		}
+		// A null member removes the field in merge-patch terms; only optional fields may be removed
+		if string(fields[field]) == "null" {
+			if !optional {
+				errs.Add(field, "required", fmt.Sprintf("Field %q cannot be removed", field))
+				continue
+			}
+			cleared = append(cleared, field)
+		}
+	}
+	checkStockNotSet(c.Body(), &errs)
+	if err := errs.Err(); err != nil {
+		return h.respondError(c, err)
 	}
 
-	if req.UserID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "User ID is required",
-		})
+	var req input.PatchProductRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return h.respondError(c, errInvalidBody)
 	}
 
-	if req.Quantity <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Quantity must be greater than 0",
-		})
+	empty := ""
+	for _, field := range cleared {
+		switch field {
+		case "sku":
+			req.SKU = &empty
+		case "description":
+			req.Description = &empty
+		case "category":
+			req.Category = &empty
+		case "barcode":
+			req.Barcode = &empty
+		case "reorder_threshold":
+			req.ClearReorderThreshold = true
+		case "max_backorder_depth":
+			req.ClearMaxBackorderDepth = true
+		case "min_order_quantity":
+			req.ClearMinOrderQuantity = true
+		case "max_order_quantity":
+			req.ClearMaxOrderQuantity = true
+		case "order_quantity_step":
+			req.ClearOrderQuantityStep = true
+		case "max_per_user":
+			req.ClearMaxPerUser = true
+		case "max_per_user_window_seconds":
+			req.ClearMaxPerUserWindowSeconds = true
+		}
 	}
 
-	order, err := h.orderUseCase.CreateOrder(c.Context(), req)
+	if err := h.validator.Struct(&req).Err(); err != nil {
+		return h.respondError(c, err)
+	}
+
+	product, err := h.productUseCase.PatchProduct(c.UserContext(), id, req)
 	if err != nil {
-		h.logger.Error("Failed to create order", "error", err)
+		h.logger.Error("Failed to patch product", "id", id, "error", err)
+		return h.respondError(c, err)
+	}
 
-		// Check for specific error types
-		errMsg := err.Error()
-		if contains(errMsg, "not found") {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": errMsg,
-			})
+	return c.JSON(fiber.Map{
+		"message": "Product updated successfully",
+		"data":    product,
+	})
+}
+
+// checkStockNotSet reports a stock member in a product update, in place of
+// the unknown-field violation strict mode reports for it. Overwriting stock
+// would lose changes made since the client read it; stock changes go through
+// the restock and adjustment endpoints, which apply deltas.
+func checkStockNotSet(body []byte, errs *validation.Errors) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return
+	}
+	if _, ok := fields["stock"]; !ok {
+		return
+	}
+
+	kept := (*errs)[:0]
+	for _, e := range *errs {
+		if e.Field != "stock" {
+			kept = append(kept, e)
 		}
-		if contains(errMsg, "insufficient stock") {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": errMsg,
-			})
+	}
+	*errs = kept
+	errs.Add("stock", "read_only", "stock cannot be set on a product; use POST /api/v1/products/{id}/restock or /api/v1/products/{id}/adjustments")
+}
+
+func (h *Handler) DeleteProduct(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	if err := h.productUseCase.DeleteProduct(c.UserContext(), id); err != nil {
+		h.logger.Error("Failed to delete product", "id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Product deleted successfully",
+	})
+}
+
+// ListStockMovements returns a product's stock ledger, newest first.
+func (h *Handler) ListStockMovements(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	movements, nextCursor, err := h.stockUseCase.ListStockMovements(c.UserContext(), id, page)
+	if err != nil {
+		h.logger.Error("Failed to list stock movements", "product_id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	if movements == nil {
+		movements = []*entity.StockMovement{}
+	}
+
+	return c.JSON(fiber.Map{
+		"message":     "Stock movements retrieved successfully",
+		"data":        movements,
+		"count":       len(movements),
+		"next_cursor": nextCursorValue(nextCursor),
+	})
+}
+
+// Restock records goods received for a product.
+func (h *Handler) Restock(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	var req input.RestockRequest
+	if err := h.bind(c, &req); err != nil {
+		return h.respondError(c, err)
+	}
+
+	product, movement, err := h.stockUseCase.Restock(c.UserContext(), id, req)
+	if err != nil {
+		h.logger.Error("Failed to restock product", "product_id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Product restocked successfully",
+		"data": fiber.Map{
+			"product":  product,
+			"movement": movement,
+		},
+	})
+}
+
+// AdjustStock corrects a product's stock by a signed delta.
+func (h *Handler) AdjustStock(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	var req input.StockAdjustmentRequest
+	if err := h.bind(c, &req); err != nil {
+		return h.respondError(c, err)
+	}
+
+	product, movement, err := h.stockUseCase.AdjustStock(c.UserContext(), id, req)
+	if err != nil {
+		h.logger.Error("Failed to adjust product stock", "product_id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Stock adjusted successfully",
+		"data": fiber.Map{
+			"product":  product,
+			"movement": movement,
+		},
+	})
+}
+
+// ListStockLevels returns a product's stock in every warehouse.
+func (h *Handler) ListStockLevels(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	levels, err := h.stockUseCase.ListStockLevels(c.UserContext(), id)
+	if err != nil {
+		h.logger.Error("Failed to list stock levels", "product_id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	if levels == nil {
+		levels = []*entity.WarehouseStock{}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Stock levels retrieved successfully",
+		"data":    levels,
+		"count":   len(levels),
+	})
+}
+
+// TransferStock moves a product's stock from one warehouse to another.
+func (h *Handler) TransferStock(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid product ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	var req input.TransferStockRequest
+	errs, err := h.decode(c, &req)
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	if req.FromWarehouseID > 0 && req.FromWarehouseID == req.ToWarehouseID {
+		errs.Add("to_warehouse_id", "conflict", "Destination warehouse must differ from the source")
+	}
+
+	if err := errs.Err(); err != nil {
+		return h.respondError(c, err)
+	}
+
+	transfer, levels, err := h.stockUseCase.TransferStock(c.UserContext(), id, req)
+	if err != nil {
+		h.logger.Error("Failed to transfer product stock", "product_id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Stock transferred successfully",
+		"data": fiber.Map{
+			"transfer":     transfer,
+			"stock_levels": levels,
+		},
+	})
+}
+
+// Order handlers
+func (h *Handler) CreateOrder(c *fiber.Ctx) error {
+	var req input.CreateOrderRequest
+	if err := h.parse(c, &req); err != nil {
+		return h.respondError(c, err)
+	}
+
+	// An authenticated caller orders as themselves unless user_id names
+	// someone else, which the order use case allows only to staff
+	if principal, ok := middleware.PrincipalFrom(c); ok && req.UserID == "" {
+		req.UserID = principal.Subject
+	}
+	errs := h.check(c, &req)
+
+	// The Idempotency-Key header and the body field are interchangeable, but
+	// must agree when both are sent. Without either the order is not deduplicated.
+	if headerKey := c.Get(middleware.IdempotencyKeyHeader); headerKey != "" {
+		if req.IdempotencyKey != "" && req.IdempotencyKey != headerKey {
+			errs.Add("idempotency_key", "mismatch", "idempotency_key does not match the Idempotency-Key header")
 		}
+		req.IdempotencyKey = headerKey
+	}
+
+	validateOrderLines(req, &errs)
+
+	if err := errs.Err(); err != nil {
+		return h.respondError(c, err)
+	}
+
+	order, replayed, err := h.orderUseCase.CreateOrder(c.UserContext(), req)
+	if err != nil {
+		h.logger.Error("Failed to create order", "error", err)
+		return h.respondError(c, err)
+	}
+
+	// A replay answers as the original did, as a response replayed by the
+	// Idempotency middleware does, and is flagged only by the header
+	if replayed {
+		c.Set(middleware.IdempotentReplayedHeader, "true")
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create order",
+	// A backorder is accepted but cannot be fulfilled until stock arrives
+	if order.Status == entity.OrderStatusBackordered {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Order backordered until stock is available",
+			"data":    order,
 		})
 	}
 
@@ -160,20 +616,242 @@ func (h *Handler) CreateOrder(c *fiber.Ctx) error {
 	})
 }
 
-// Helper function to check if a string contains a substring
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr ||
-		(len(s) > len(substr) &&
-			(s[:len(substr)] == substr ||
-				s[len(s)-len(substr):] == substr ||
-				containsSubstring(s, substr))))
+// validateOrderLines adds the checks on an order's lines that its validate
+// tags cannot express: a single-product order needs product_id and quantity,
+// items replace them rather than add to them, and each product may appear on
+// only one line.
+func validateOrderLines(req input.CreateOrderRequest, errs *validation.Errors) {
+	if len(req.Items) == 0 {
+		if req.ProductID == 0 {
+			errs.Add("product_id", "required", "product_id is required")
+		}
+		if req.Quantity == 0 {
+			errs.Add("quantity", "required", "quantity is required")
+		}
+		return
+	}
+
+	if req.ProductID != 0 || req.Quantity != 0 {
+		errs.Add("items", "conflict", "Send either items or product_id and quantity, not both")
+	}
+
+	seen := make(map[int]bool, len(req.Items))
+	for i, item := range req.Items {
+		if item.ProductID > 0 && seen[item.ProductID] {
+			errs.Add(fmt.Sprintf("items[%d].product_id", i), "duplicate", "Each product may appear only once per order")
+		}
+		seen[item.ProductID] = true
+	}
 }
 
-func containsSubstring(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
+func (h *Handler) GetOrder(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid order ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	order, err := h.orderUseCase.GetOrder(c.UserContext(), id)
+	if err != nil {
+		h.logger.Error("Failed to get order", "id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Order retrieved successfully",
+		"data":    order,
+	})
+}
+
+func (h *Handler) ListOrders(c *fiber.Ctx) error {
+	req := input.ListOrdersRequest{
+		UserID: c.Query("user_id"),
+		Status: c.Query("status"),
+	}
+
+	if productID := c.Query("product_id"); productID != "" {
+		id, err := strconv.Atoi(productID)
+		if err != nil || id <= 0 {
+			return h.respondError(c, domainerr.Invalid("product_id", "invalid", "Invalid product ID"))
 		}
+		req.ProductID = id
+	}
+
+	switch req.Status {
+	case "", entity.OrderStatusBackordered, entity.OrderStatusPending, entity.OrderStatusCompleted, entity.OrderStatusCancelled:
+	default:
+		return h.respondError(c, domainerr.Invalid("status", "enum", "Invalid order status"))
+	}
+
+	var err error
+	if req.Page, err = parsePageRequest(c); err != nil {
+		return h.respondError(c, err)
+	}
+	if req.CreatedFrom, err = parseTimeQuery(c.Query("created_from"), false); err != nil {
+		return h.respondError(c, domainerr.Invalid("created_from", "format", "Invalid created_from, expected RFC 3339 timestamp or YYYY-MM-DD"))
+	}
+	if req.CreatedTo, err = parseTimeQuery(c.Query("created_to"), true); err != nil {
+		return h.respondError(c, domainerr.Invalid("created_to", "format", "Invalid created_to, expected RFC 3339 timestamp or YYYY-MM-DD"))
+	}
+
+	orders, nextCursor, err := h.orderUseCase.ListOrders(c.UserContext(), req)
+	if err != nil {
+		h.logger.Error("Failed to list orders", "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":     "Orders retrieved successfully",
+		"data":        orders,
+		"count":       len(orders),
+		"next_cursor": nextCursorValue(nextCursor),
+	})
+}
+
+func (h *Handler) CompleteOrder(c *fiber.Ctx) error {
+	return h.updateOrderStatus(c, h.orderUseCase.CompleteOrder, "Order completed successfully")
+}
+
+func (h *Handler) CancelOrder(c *fiber.Ctx) error {
+	return h.updateOrderStatus(c, h.orderUseCase.CancelOrder, "Order cancelled successfully")
+}
+
+func (h *Handler) updateOrderStatus(c *fiber.Ctx, transition func(ctx context.Context, id int) (*entity.Order, error), message string) error {
+	id, err := parseID(c, "Invalid order ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	order, err := transition(c.UserContext(), id)
+	if err != nil {
+		h.logger.Error("Failed to update order status", "id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    order,
+	})
+}
+
+// Warehouse handlers
+func (h *Handler) CreateWarehouse(c *fiber.Ctx) error {
+	var req input.CreateWarehouseRequest
+	if err := h.bind(c, &req); err != nil {
+		return h.respondError(c, err)
+	}
+
+	warehouse, err := h.warehouseUseCase.CreateWarehouse(c.UserContext(), req)
+	if err != nil {
+		h.logger.Error("Failed to create warehouse", "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Warehouse created successfully",
+		"data":    warehouse,
+	})
+}
+
+func (h *Handler) GetWarehouse(c *fiber.Ctx) error {
+	id, err := parseID(c, "Invalid warehouse ID")
+	if err != nil {
+		return h.respondError(c, err)
+	}
+
+	warehouse, err := h.warehouseUseCase.GetWarehouse(c.UserContext(), id)
+	if err != nil {
+		h.logger.Error("Failed to get warehouse", "id", id, "error", err)
+		return h.respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Warehouse retrieved successfully",
+		"data":    warehouse,
+	})
+}
+
+func (h *Handler) GetAllWarehouses(c *fiber.Ctx) error {
+	warehouses, err := h.warehouseUseCase.GetAllWarehouses(c.UserContext())
+	if err != nil {
+		h.logger.Error("Failed to get warehouses", "error", err)
+		return h.respondError(c, err)
+	}
+
+	if warehouses == nil {
+		warehouses = []*entity.Warehouse{}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Warehouses retrieved successfully",
+		"data":    warehouses,
+		"count":   len(warehouses),
+	})
+}
+
+// parseID reads the :id route parameter.
+func parseID(c *fiber.Ctx, message string) (int, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, domainerr.Invalid("id", "invalid", message)
+	}
+	return id, nil
+}
+
+// parsePageRequest reads the limit, cursor and sort query parameters.
+func parsePageRequest(c *fiber.Ctx) (input.PageRequest, error) {
+	page := input.PageRequest{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+
+	limit, err := parseLimit(c)
+	if err != nil {
+		return page, err
+	}
+	page.Limit = limit
+
+	return page, nil
+}
+
+// parseLimit reads the optional limit query parameter; zero means the default.
+func parseLimit(c *fiber.Ctx) (int, error) {
+	limit := c.Query("limit")
+	if limit == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > repository.MaxPageLimit {
+		return 0, domainerr.Invalid("limit", "range", fmt.Sprintf("limit must be between 1 and %d", repository.MaxPageLimit))
+	}
+	return n, nil
+}
+
+// nextCursorValue renders an exhausted listing as a null cursor.
+func nextCursorValue(cursor string) interface{} {
+	if cursor == "" {
+		return nil
+	}
+	return cursor
+}
+
+// parseTimeQuery accepts an RFC 3339 timestamp or a bare YYYY-MM-DD date. A
+// bare date used as an upper bound covers the whole day.
+func parseTimeQuery(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Local(), nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
 	}
-	return false
+	return t, nil
 }
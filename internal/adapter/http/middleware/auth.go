@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type AuthConfig struct {
+	APIKeys input.APIKeyUseCase
+	// Tokens verifies JWTs. Without it only API keys are accepted.
+	Tokens output.TokenVerifier
+	Logger logger.Logger
+}
+
+// Authenticate identifies the caller from an X-API-Key header or an
+// Authorization bearer credential, either an API key or a JWT. Requests
+// without credentials continue anonymously, for RequireScope to turn away
+// from the routes that need them; credentials that do not verify are
+// rejected on every route. The principal is carried in the request's user
+// context, which handlers pass on to the use cases.
+func Authenticate(config AuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		credential := c.Get(APIKeyHeader)
+		if credential == "" {
+			scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+			if ok && strings.EqualFold(scheme, "Bearer") {
+				credential = strings.TrimSpace(token)
+			}
+		}
+		if credential == "" {
+			return c.Next()
+		}
+
+		var principal *entity.Principal
+		var err error
+		switch {
+		case strings.HasPrefix(credential, entity.APIKeyPrefix):
+			principal, err = config.APIKeys.AuthenticateAPIKey(c.Context(), credential)
+		case config.Tokens != nil:
+			principal, err = config.Tokens.Verify(credential)
+		default:
+			err = domainerr.ErrInvalidCredentials.Withf("bearer tokens are not accepted; use an API key")
+		}
+		if err != nil {
+			if errors.Is(err, domainerr.ErrInvalidCredentials) {
+				config.Logger.Warn("Rejected credentials", "path", c.Path(), "error", err)
+			} else {
+				config.Logger.Error("Failed to authenticate request", "path", c.Path(), "error", err)
+			}
+			challenge(c)
+			return err
+		}
+
+		c.SetUserContext(authz.WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+// AllowAll stands in for Authenticate when authentication is disabled: every
+// request acts for the system and holds every permission.
+func AllowAll() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(authz.AsSystem(c.UserContext()))
+		return c.Next()
+	}
+}
+
+// RequireScope rejects requests whose principal was not granted scope, and
+// anonymous requests.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			challenge(c)
+			return domainerr.ErrUnauthenticated
+		}
+		if !principal.HasScope(scope) {
+			return domainerr.ErrInsufficientScope.Withf("the %s scope is required", scope)
+		}
+		return c.Next()
+	}
+}
+
+// PrincipalFrom returns the principal Authenticate identified, if any.
+func PrincipalFrom(c *fiber.Ctx) (*entity.Principal, bool) {
+	return authz.PrincipalFrom(c.UserContext())
+}
+
+// challenge tells the client which credentials are accepted, as a 401
+// response must (RFC 9110).
+func challenge(c *fiber.Ctx) {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	MaxIdempotencyKeyLength = 255
+)
+
+type IdempotencyConfig struct {
+	Store  repository.IdempotencyRepository
+	Logger logger.Logger
+
+	// TTL is how long a recorded response is replayed. Defaults to 24 hours.
+	TTL time.Duration
+	// RequireKey rejects POST requests that do not carry an Idempotency-Key.
+	RequireKey bool
+}
+
+// Idempotency records the status code and body of the first POST made with a
+// given Idempotency-Key header and replays them byte-for-byte on retries.
+// Reusing a key with a different request body is rejected, as is a retry that
+// arrives while the original request is still being processed. Responses with
+// a 5xx status, or whose handler panics, are not recorded so the client may
+// retry them. It runs after Authenticate, whose principal scopes the keys.
+func Idempotency(config IdempotencyConfig) fiber.Handler {
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodPost {
+			return c.Next()
+		}
+
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			if config.RequireKey {
+				return fiber.NewError(fiber.StatusBadRequest, "Idempotency-Key header is required")
+			}
+			return c.Next()
+		}
+		if len(key) > MaxIdempotencyKeyLength {
+			return fiber.NewError(fiber.StatusBadRequest, "Idempotency-Key header is too long")
+		}
+
+		// Keys are scoped per caller, so one caller cannot replay another's response
+		scope := c.Method() + " " + c.Path()
+		if principal, ok := PrincipalFrom(c); ok {
+			scope = principal.Subject + " " + scope
+		}
+		hash := requestHash(c)
+		now := time.Now()
+
+		existing, err := config.Store.Reserve(c.Context(), &entity.IdempotencyRecord{
+			Key:         key,
+			Scope:       scope,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(config.TTL),
+		})
+		if err != nil {
+			config.Logger.Error("Failed to reserve idempotency key", "key", key, "error", err)
+			return err
+		}
+
+		if existing != nil {
+			if existing.RequestHash != hash {
+				return fiber.NewError(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+			}
+			if !existing.Completed() {
+				return fiber.NewError(fiber.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			}
+
+			config.Logger.Info("Replaying idempotent response", "key", key, "scope", scope)
+
+			c.Set(IdempotentReplayedHeader, "true")
+			if existing.ContentType != "" {
+				c.Set(fiber.HeaderContentType, existing.ContentType)
+			}
+			return c.Status(existing.StatusCode).Send(existing.ResponseBody)
+		}
+
+		// A panicking handler never produced a response to record; release the
+		// key before the panic reaches the recover middleware, so a retry runs
+		// instead of being refused as still in progress until the key expires
+		defer func() {
+			if r := recover(); r != nil {
+				releaseKey(c, config, scope, key)
+				panic(r)
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			releaseKey(c, config, scope, key)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			releaseKey(c, config, scope, key)
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		contentType := string(c.Response().Header.ContentType())
+		if err := config.Store.Complete(c.Context(), scope, key, status, contentType, body); err != nil {
+			config.Logger.Error("Failed to record idempotent response", "key", key, "error", err)
+		}
+
+		return nil
+	}
+}
+
+func releaseKey(c *fiber.Ctx, config IdempotencyConfig, scope, key string) {
+	if err := config.Store.Release(c.Context(), scope, key); err != nil {
+		config.Logger.Error("Failed to release idempotency key", "key", key, "error", err)
+	}
+}
+
+func requestHash(c *fiber.Ctx) string {
+	sum := sha256.Sum256(c.Body())
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WaveCE29/product_order_system/internal/testenv"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// TestPanickingHandlerReleasesKey checks that a retry of a request whose
+// handler panicked runs again rather than being refused as in progress.
+func TestPanickingHandlerReleasesKey(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+
+	calls := 0
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(Idempotency(IdempotencyConfig{Store: env.Idempotency, Logger: env.Logger}))
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+		return c.Status(fiber.StatusCreated).SendString("created")
+	})
+
+	send := func() *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"items":[]}`))
+		req.Header.Set(IdempotencyKeyHeader, "panic-key")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp
+	}
+
+	if resp := send(); resp.StatusCode != fiber.StatusInternalServerError {
+		t.Fatalf("panicking request: status %d, want %d", resp.StatusCode, fiber.StatusInternalServerError)
+	}
+
+	resp := send()
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("retry: status %d, want %d", resp.StatusCode, fiber.StatusCreated)
+	}
+	if resp.Header.Get(IdempotentReplayedHeader) != "" {
+		t.Error("retry was replayed instead of run")
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,346 @@
+// Package openapi builds an OpenAPI 3.1 document. Request and response
+// bodies are described by reflecting over Go types: properties come from
+// their JSON tags and constraints from their validate tags, so the document
+// follows the types the handlers actually decode and encode.
+package openapi
+
+import (
+	_ "embed"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/validation"
+)
+
+// Version is the OpenAPI version documents are written in.
+const Version = "3.1.0"
+
+// DocsPage is an HTML page that renders the document served next to it at
+// openapi.json.
+//
+//go:embed docs.html
+var DocsPage []byte
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to the operations on one path.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	// Security lists alternative requirements, each mapping security scheme
+	// names to the scopes they must grant.
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes a way of authenticating: an "apiKey" sent in a
+// header, or an "http" scheme such as bearer.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Schema is the subset of JSON Schema the generator produces. Type is a
+// string, or a list of strings for nullable values.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Object returns an object schema with the given properties, all required.
+func Object(properties map[string]*Schema) *Schema {
+	s := &Schema{Type: "object", Properties: properties}
+	for name := range properties {
+		s.Required = append(s.Required, name)
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// Type returns a schema of a single JSON type.
+func Type(name string) *Schema {
+	return &Schema{Type: name}
+}
+
+// Nullable returns a copy of s, a schema of a single JSON type, that also
+// allows null.
+func Nullable(s *Schema) *Schema {
+	nullable := *s
+	if name, ok := s.Type.(string); ok {
+		nullable.Type = []string{name, "null"}
+	}
+	return &nullable
+}
+
+// Builder accumulates the operations and component schemas of a document.
+type Builder struct {
+	doc   Document
+	types map[string]reflect.Type
+}
+
+func NewBuilder(info Info) *Builder {
+	return &Builder{
+		doc: Document{
+			OpenAPI:    Version,
+			Info:       info,
+			Paths:      make(map[string]*PathItem),
+			Components: Components{Schemas: make(map[string]*Schema)},
+		},
+		types: make(map[string]reflect.Type),
+	}
+}
+
+// Add registers op under method and path, a path template such as
+// /products/{id}.
+func (b *Builder) Add(method, path string, op *Operation) {
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// AddSecurityScheme registers a security scheme operations may require.
+func (b *Builder) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	if b.doc.Components.SecuritySchemes == nil {
+		b.doc.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+	}
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// Document returns the document built so far.
+func (b *Builder) Document() *Document {
+	return &b.doc
+}
+
+// Schema describes the type of v. Named struct types are added to the
+// document's components and referenced.
+func (b *Builder) Schema(v interface{}) *Schema {
+	return b.schema(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *Builder) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		elem := t.Elem()
+		// Pointers to structs are how entities are passed around, not a sign
+		// that they may be null
+		if elem.Kind() == reflect.Struct && elem != timeType {
+			return b.schema(elem)
+		}
+		return Nullable(b.schema(elem))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Type("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Type("integer")
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return Type("number")
+	case reflect.String:
+		return Type("string")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.ref(t)
+	}
+	// Interfaces may hold anything
+	return &Schema{}
+}
+
+// ref adds the named struct type t to the components, once, and references
+// it. A name already taken by another package's type is qualified with the
+// package name.
+func (b *Builder) ref(t reflect.Type) *Schema {
+	name := t.Name()
+	if existing, ok := b.types[name]; ok && existing != t {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	if _, ok := b.types[name]; !ok {
+		b.types[name] = t
+		// Register before describing the fields so recursive types terminate
+		b.doc.Components.Schemas[name] = &Schema{}
+		*b.doc.Components.Schemas[name] = *b.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object describes a struct's JSON properties. Fields of embedded structs
+// without a JSON name are promoted, as encoding/json does.
+func (b *Builder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(s, t)
+	return s
+}
+
+func (b *Builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			b.addFields(s, ft)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		property := b.schema(sf.Type)
+		if constrain(property, sf.Type, sf.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+}
+
+// constrain adds the constraints of a validate tag to a property schema and
+// reports whether the tag makes the property required.
+func constrain(s *Schema, t reflect.Type, tag string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	for _, rule := range validation.ParseTag(tag) {
+		switch rule.Name {
+		case "required":
+			required = true
+			if t.Kind() == reflect.String {
+				s.MinLength = intPtr(1)
+			}
+		case "min", "max", "len":
+			limit, err := strconv.ParseFloat(rule.Param, 64)
+			if err != nil {
+				continue
+			}
+			switch t.Kind() {
+			case reflect.String:
+				setLimit(rule.Name, int(limit), &s.MinLength, &s.MaxLength)
+			case reflect.Slice, reflect.Array, reflect.Map:
+				setLimit(rule.Name, int(limit), &s.MinItems, &s.MaxItems)
+			default:
+				if rule.Name != "max" {
+					s.Minimum = &limit
+				}
+				if rule.Name != "min" {
+					s.Maximum = &limit
+				}
+			}
+		case "oneof":
+			for _, value := range strings.Fields(rule.Param) {
+				s.Enum = append(s.Enum, value)
+			}
+		case "pattern":
+			s.Pattern = rule.Param
+		}
+	}
+	return required
+}
+
+func setLimit(rule string, limit int, min, max **int) {
+	if rule != "max" {
+		*min = intPtr(limit)
+	}
+	if rule != "min" {
+		*max = intPtr(limit)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -1,7 +1,12 @@
 package router
 
 import (
+	"encoding/json"
+
 	"github.com/WaveCE29/product_order_system/internal/adapter/http/handler"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/middleware"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/openapi"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 	"github.com/WaveCE29/product_order_system/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -9,7 +14,16 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
-func SetupRoutes(app *fiber.App, h *handler.Handler, logger logger.Logger) {
+// Middleware holds the application middleware constructed in main. Without
+// Authenticate and RequireScope every route is open and every request acts
+// for the system.
+type Middleware struct {
+	Idempotency  fiber.Handler
+	Authenticate fiber.Handler
+	RequireScope func(scope string) fiber.Handler
+}
+
+func SetupRoutes(app *fiber.App, h *handler.Handler, mw Middleware, logger logger.Logger) {
 	// Middleware
 	app.Use(recover.New())
 	app.Use(requestid.New())
@@ -18,6 +32,14 @@ func SetupRoutes(app *fiber.App, h *handler.Handler, logger logger.Logger) {
 		AllowMethods: "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
 		AllowHeaders: "*",
 	}))
+	if mw.Authenticate == nil || mw.RequireScope == nil {
+		mw.Authenticate = middleware.AllowAll()
+		mw.RequireScope = func(string) fiber.Handler {
+			return func(c *fiber.Ctx) error { return c.Next() }
+		}
+	}
+	app.Use(mw.Authenticate)
+	app.Use(mw.Idempotency)
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -30,21 +52,62 @@ func SetupRoutes(app *fiber.App, h *handler.Handler, logger logger.Logger) {
 	// API routes
 	api := app.Group("/api/v1")
 
+	// API description; the document is static, so it is encoded once
+	spec, err := json.Marshal(apiSpec())
+	if err != nil {
+		panic("router: failed to encode OpenAPI document: " + err.Error())
+	}
+	api.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(spec)
+	})
+	api.Get("/docs", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Send(openapi.DocsPage)
+	})
+
+	// Scopes guarding each group of routes
+	productsRead, productsWrite := mw.RequireScope(entity.ScopeProductsRead), mw.RequireScope(entity.ScopeProductsWrite)
+	stockRead, stockWrite := mw.RequireScope(entity.ScopeStockRead), mw.RequireScope(entity.ScopeStockWrite)
+	warehousesRead, warehousesWrite := mw.RequireScope(entity.ScopeWarehousesRead), mw.RequireScope(entity.ScopeWarehousesWrite)
+	ordersRead, ordersWrite := mw.RequireScope(entity.ScopeOrdersRead), mw.RequireScope(entity.ScopeOrdersWrite)
+
 	// Product routes
 	products := api.Group("/products")
-	products.Post("/", h.CreateProduct)
-	products.Get("/", h.GetAllProducts)
-	products.Get("/:id", h.GetProduct)
+	products.Post("/", productsWrite, h.CreateProduct)
+	products.Get("/", productsRead, h.GetAllProducts)
+	products.Get("/search", productsRead, h.SearchProducts)
+	products.Get("/low-stock", productsRead, h.ListLowStockProducts)
+	products.Get("/sku/:sku", productsRead, h.GetProductBySKU)
+	products.Get("/:id", productsRead, h.GetProduct)
+	products.Put("/:id", productsWrite, h.UpdateProduct)
+	products.Patch("/:id", productsWrite, h.PatchProduct)
+	products.Delete("/:id", productsWrite, h.DeleteProduct)
+	products.Get("/:id/stock-movements", stockRead, h.ListStockMovements)
+	products.Post("/:id/restock", stockWrite, h.Restock)
+	products.Post("/:id/adjustments", stockWrite, h.AdjustStock)
+	products.Get("/:id/stock-levels", stockRead, h.ListStockLevels)
+	products.Post("/:id/transfers", stockWrite, h.TransferStock)
+
+	// Warehouse routes
+	warehouses := api.Group("/warehouses")
+	warehouses.Post("/", warehousesWrite, h.CreateWarehouse)
+	warehouses.Get("/", warehousesRead, h.GetAllWarehouses)
+	warehouses.Get("/:id", warehousesRead, h.GetWarehouse)
 
 	// Order routes
 	orders := api.Group("/orders")
-	orders.Post("/", h.CreateOrder)
+	orders.Post("/", ordersWrite, h.CreateOrder)
+	orders.Get("/", ordersRead, h.ListOrders)
+	orders.Get("/:id", ordersRead, h.GetOrder)
+	orders.Patch("/:id/complete", ordersWrite, h.CompleteOrder)
+	orders.Patch("/:id/cancel", ordersWrite, h.CancelOrder)
 
 	// Legacy routes (without /api/v1 prefix for compatibility)
-	app.Post("/products", h.CreateProduct)
-	app.Get("/products", h.GetAllProducts)
-	app.Get("/products/:id", h.GetProduct)
-	app.Post("/orders", h.CreateOrder)
+	app.Post("/products", productsWrite, h.CreateProduct)
+	app.Get("/products", productsRead, h.GetAllProducts)
+	app.Get("/products/:id", productsRead, h.GetProduct)
+	app.Post("/orders", ordersWrite, h.CreateOrder)
 
 	logger.Info("Routes configured successfully")
 }
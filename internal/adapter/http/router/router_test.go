@@ -0,0 +1,220 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/handler"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/middleware"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/validation"
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/testenv"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// TestSpecCoversRoutes fails when a route registered by SetupRoutes is missing
+// from the OpenAPI document, or the document describes a route that is not
+// registered.
+func TestSpecCoversRoutes(t *testing.T) {
+	log := logger.NewNopLogger()
+	app := fiber.New()
+	h := handler.NewHandler(nil, nil, nil, nil, validation.New(false), log)
+	SetupRoutes(app, h, Middleware{Idempotency: func(c *fiber.Ctx) error { return c.Next() }}, log)
+
+	registered := make(map[string]bool)
+	for _, r := range app.GetRoutes(true) {
+		// Fiber registers a HEAD route alongside every GET
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		path := r.Path
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		path = routeParam.ReplaceAllString(path, "{$1}")
+		registered[r.Method+" "+path] = true
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range apiSpec().Paths {
+		for method := range *item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	for route := range registered {
+		if !documented[route] {
+			t.Errorf("route %s is missing from the OpenAPI document", route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			t.Errorf("OpenAPI document describes %s, which is not registered", route)
+		}
+	}
+}
+
+// scopeTokens accepts any bearer token as granting the comma separated
+// scopes it names.
+type scopeTokens struct{}
+
+func (scopeTokens) Verify(token string) (*entity.Principal, error) {
+	return &entity.Principal{Subject: "test", Scopes: strings.Split(token, ","), Method: entity.AuthMethodJWT}, nil
+}
+
+// TestRoutesRequireDocumentedScope fails when a route is guarded by a scope
+// other than the one the OpenAPI document lists for it.
+func TestRoutesRequireDocumentedScope(t *testing.T) {
+	log := logger.NewNopLogger()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler(log)})
+	h := handler.NewHandler(nil, nil, nil, nil, validation.New(false), log)
+	SetupRoutes(app, h, Middleware{
+		Idempotency:  func(c *fiber.Ctx) error { return c.Next() },
+		Authenticate: middleware.Authenticate(middleware.AuthConfig{Tokens: scopeTokens{}, Logger: log}),
+		RequireScope: middleware.RequireScope,
+	}, log)
+
+	for _, r := range apiRoutes() {
+		if r.scope == "" {
+			t.Errorf("route %s %s has no scope", r.method, r.path)
+			continue
+		}
+		path := pathParam.ReplaceAllString(r.path, "1")
+
+		var others []string
+		for _, scope := range entity.Scopes {
+			if scope != r.scope {
+				others = append(others, scope)
+			}
+		}
+
+		for _, tc := range []struct {
+			token  string
+			denied int
+		}{
+			{"", fiber.StatusUnauthorized},
+			{strings.Join(others, ","), fiber.StatusForbidden},
+			{r.scope, 0},
+		} {
+			req := httptest.NewRequest(r.method, path, nil)
+			if tc.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tc.token)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s %s: %v", r.method, path, err)
+			}
+			status := resp.StatusCode
+			// With the scope the request reaches the handler, whatever it answers
+			if tc.denied == 0 && (status == fiber.StatusUnauthorized || status == fiber.StatusForbidden) {
+				t.Errorf("%s %s with scope %s: status %d, want access", r.method, path, r.scope, status)
+			}
+			if tc.denied != 0 && status != tc.denied {
+				t.Errorf("%s %s with scopes %q: status %d, want %d", r.method, path, tc.token, status, tc.denied)
+			}
+		}
+	}
+
+	for _, path := range []string{"/health", "/api/v1/openapi.json", "/api/v1/docs"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("GET %s without credentials: status %d, want 200", path, resp.StatusCode)
+		}
+	}
+}
+
+// TestProductUpdatesRejectStock fails when a product update accepts stock,
+// which only restocks and adjustments may change.
+func TestProductUpdatesRejectStock(t *testing.T) {
+	log := logger.NewNopLogger()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler(log)})
+	h := handler.NewHandler(nil, nil, nil, nil, validation.New(false), log)
+	SetupRoutes(app, h, Middleware{Idempotency: func(c *fiber.Ctx) error { return c.Next() }}, log)
+
+	for _, tc := range []struct{ method, contentType string }{
+		{fiber.MethodPut, fiber.MIMEApplicationJSON},
+		{fiber.MethodPatch, "application/merge-patch+json"},
+	} {
+		req := httptest.NewRequest(tc.method, "/api/v1/products/1", strings.NewReader(`{"name":"x","stock":0}`))
+		req.Header.Set(fiber.HeaderContentType, tc.contentType)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.method, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusBadRequest || !strings.Contains(string(body), `"code":"read_only"`) {
+			t.Errorf("%s with stock: status %d, body %s; want 400 read_only", tc.method, resp.StatusCode, body)
+		}
+	}
+}
+
+// TestReplayedOrdersAreFlaggedByHeaderOnly fails when a replayed order
+// response disagrees with its Idempotent-Replayed header or answers with a
+// different status than the original, whether the Idempotency middleware or
+// the order's own idempotency key replayed it.
+func TestReplayedOrdersAreFlaggedByHeaderOnly(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	log := env.Logger
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler(log)})
+	h := handler.NewHandler(env.ProductUseCase, env.OrderUseCase, env.StockUseCase, env.WarehouseUseCase, validation.New(false), log)
+	SetupRoutes(app, h, Middleware{
+		Idempotency: middleware.Idempotency(middleware.IdempotencyConfig{Store: env.Idempotency, Logger: log}),
+	}, log)
+
+	product, err := env.ProductUseCase.CreateProduct(authz.AsSystem(context.Background()), input.CreateProductRequest{Name: "Widget", Stock: 10})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	post := func(body, key string) (*http.Response, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/api/v1/orders", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("POST /api/v1/orders: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	for _, tc := range []struct{ name, body, header string }{
+		{"Idempotency-Key header", fmt.Sprintf(`{"product_id":%d,"user_id":"u1","quantity":1}`, product.ID), "header-key"},
+		{"idempotency_key field", fmt.Sprintf(`{"product_id":%d,"user_id":"u1","quantity":1,"idempotency_key":"body-key"}`, product.ID), ""},
+	} {
+		first, firstBody := post(tc.body, tc.header)
+		retry, retryBody := post(tc.body, tc.header)
+
+		if first.StatusCode != fiber.StatusCreated || first.Header.Get(middleware.IdempotentReplayedHeader) != "" {
+			t.Errorf("%s: first response status %d, replayed header %q; want 201 and no header", tc.name, first.StatusCode, first.Header.Get(middleware.IdempotentReplayedHeader))
+		}
+		if retry.StatusCode != fiber.StatusCreated {
+			t.Errorf("%s: retry status %d, want 201 like the original", tc.name, retry.StatusCode)
+		}
+		if retry.Header.Get(middleware.IdempotentReplayedHeader) != "true" {
+			t.Errorf("%s: retry is missing the %s header", tc.name, middleware.IdempotentReplayedHeader)
+		}
+		for _, body := range []string{firstBody, retryBody} {
+			if strings.Contains(body, `"replayed"`) {
+				t.Errorf("%s: body reports replayed itself: %s", tc.name, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package router
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/handler"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/middleware"
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/openapi"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// route describes one route registered by SetupRoutes for the OpenAPI
+// document. Successful responses wrap data in the handlers' envelope of
+// message and data; list adds count, and paged adds next_cursor. scope is the
+// scope the caller must be granted.
+type route struct {
+	method      string
+	path        string
+	id          string
+	summary     string
+	tag         string
+	scope       string
+	query       []openapi.Parameter
+	body        interface{}
+	contentType string
+	status      int
+	data        interface{}
+	list        bool
+	paged       bool
+	extra       map[int]string
+	errors      []int
+	deprecated  bool
+}
+
+// stockChangeData is the data of a restock or adjustment response.
+type stockChangeData struct {
+	Product  *entity.Product       `json:"product"`
+	Movement *entity.StockMovement `json:"movement"`
+}
+
+// stockTransferData is the data of a transfer response.
+type stockTransferData struct {
+	Transfer    *entity.StockTransfer    `json:"transfer"`
+	StockLevels []*entity.WarehouseStock `json:"stock_levels"`
+}
+
+func apiRoutes() []route {
+	productParams := pageParams()
+	orderParams := append([]openapi.Parameter{
+		queryParam("user_id", openapi.Type("string"), "Only orders placed by this user"),
+		queryParam("status", &openapi.Schema{Type: "string", Enum: []interface{}{
+			entity.OrderStatusBackordered, entity.OrderStatusPending, entity.OrderStatusCompleted, entity.OrderStatusCancelled,
+		}}, "Only orders with this status"),
+		queryParam("product_id", &openapi.Schema{Type: "integer", Minimum: float(1)}, "Only orders with a line for this product"),
+		queryParam("created_from", openapi.Type("string"), "Created at or after this RFC 3339 timestamp or YYYY-MM-DD date"),
+		queryParam("created_to", openapi.Type("string"), "Created at or before this RFC 3339 timestamp or YYYY-MM-DD date"),
+	}, pageParams()...)
+	searchParams := []openapi.Parameter{
+		{Name: "q", In: "query", Required: true, Description: "Full-text search query", Schema: openapi.Type("string")},
+		limitParam(),
+		queryParam("offset", &openapi.Schema{Type: "integer", Minimum: float(0)}, "Number of results to skip"),
+	}
+
+	productErrors := []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict}
+	orderErrors := []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusUnprocessableEntity}
+
+	routes := []route{
+		{method: "GET", path: "/api/v1/products", id: "ListProducts", summary: "List products", tag: "Products", scope: entity.ScopeProductsRead,
+			query: productParams, data: []*entity.Product{}, list: true, paged: true, errors: []int{fiber.StatusBadRequest}},
+		{method: "POST", path: "/api/v1/products", id: "CreateProduct", summary: "Create a product", tag: "Products", scope: entity.ScopeProductsWrite,
+			body: input.CreateProductRequest{}, status: fiber.StatusCreated, data: entity.Product{}, errors: []int{fiber.StatusBadRequest, fiber.StatusConflict}},
+		{method: "GET", path: "/api/v1/products/search", id: "SearchProducts", summary: "Search products by name, SKU, category and description", tag: "Products", scope: entity.ScopeProductsRead,
+			query: searchParams, data: []*entity.ProductSearchResult{}, list: true, errors: []int{fiber.StatusBadRequest, fiber.StatusServiceUnavailable}},
+		{method: "GET", path: "/api/v1/products/low-stock", id: "ListLowStockProducts", summary: "List products at or below their reorder threshold", tag: "Products", scope: entity.ScopeProductsRead,
+			query: productParams, data: []*entity.Product{}, list: true, paged: true, errors: []int{fiber.StatusBadRequest}},
+		{method: "GET", path: "/api/v1/products/sku/{sku}", id: "GetProductBySKU", summary: "Get a product by SKU", tag: "Products", scope: entity.ScopeProductsRead,
+			data: entity.Product{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "GET", path: "/api/v1/products/{id}", id: "GetProduct", summary: "Get a product", tag: "Products", scope: entity.ScopeProductsRead,
+			data: entity.Product{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "PUT", path: "/api/v1/products/{id}", id: "UpdateProduct", summary: "Replace a product", tag: "Products", scope: entity.ScopeProductsWrite,
+			body: input.UpdateProductRequest{}, data: entity.Product{}, errors: productErrors},
+		{method: "PATCH", path: "/api/v1/products/{id}", id: "PatchProduct", summary: "Update a product with a JSON merge patch", tag: "Products", scope: entity.ScopeProductsWrite,
+			body: input.PatchProductRequest{}, contentType: "application/merge-patch+json", data: entity.Product{}, errors: productErrors},
+		{method: "DELETE", path: "/api/v1/products/{id}", id: "DeleteProduct", summary: "Delete a product", tag: "Products", scope: entity.ScopeProductsWrite,
+			errors: productErrors},
+		{method: "GET", path: "/api/v1/products/{id}/stock-movements", id: "ListStockMovements", summary: "List a product's stock ledger, newest first", tag: "Stock", scope: entity.ScopeStockRead,
+			query: pageParams(), data: []*entity.StockMovement{}, list: true, paged: true, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "POST", path: "/api/v1/products/{id}/restock", id: "Restock", summary: "Record goods received for a product", tag: "Stock", scope: entity.ScopeStockWrite,
+			body: input.RestockRequest{}, status: fiber.StatusCreated, data: stockChangeData{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "POST", path: "/api/v1/products/{id}/adjustments", id: "AdjustStock", summary: "Correct a product's stock by a signed delta", tag: "Stock", scope: entity.ScopeStockWrite,
+			body: input.StockAdjustmentRequest{}, status: fiber.StatusCreated, data: stockChangeData{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "GET", path: "/api/v1/products/{id}/stock-levels", id: "ListStockLevels", summary: "List a product's stock in every warehouse", tag: "Stock", scope: entity.ScopeStockRead,
+			data: []*entity.WarehouseStock{}, list: true, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "POST", path: "/api/v1/products/{id}/transfers", id: "TransferStock", summary: "Move a product's stock between warehouses", tag: "Stock", scope: entity.ScopeStockWrite,
+			body: input.TransferStockRequest{}, status: fiber.StatusCreated, data: stockTransferData{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+
+		{method: "GET", path: "/api/v1/warehouses", id: "ListWarehouses", summary: "List warehouses", tag: "Warehouses", scope: entity.ScopeWarehousesRead,
+			data: []*entity.Warehouse{}, list: true},
+		{method: "POST", path: "/api/v1/warehouses", id: "CreateWarehouse", summary: "Create a warehouse", tag: "Warehouses", scope: entity.ScopeWarehousesWrite,
+			body: input.CreateWarehouseRequest{}, status: fiber.StatusCreated, data: entity.Warehouse{}, errors: []int{fiber.StatusBadRequest, fiber.StatusConflict}},
+		{method: "GET", path: "/api/v1/warehouses/{id}", id: "GetWarehouse", summary: "Get a warehouse", tag: "Warehouses", scope: entity.ScopeWarehousesRead,
+			data: entity.Warehouse{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+
+		{method: "GET", path: "/api/v1/orders", id: "ListOrders", summary: "List orders", tag: "Orders", scope: entity.ScopeOrdersRead,
+			query: orderParams, data: []*entity.Order{}, list: true, paged: true, errors: []int{fiber.StatusBadRequest}},
+		{method: "POST", path: "/api/v1/orders", id: "CreateOrder", summary: "Place an order", tag: "Orders", scope: entity.ScopeOrdersWrite,
+			body: input.CreateOrderRequest{}, status: fiber.StatusCreated, data: entity.Order{}, errors: orderErrors,
+			extra: map[int]string{
+				fiber.StatusAccepted: "The order was backordered until stock is available",
+			}},
+		{method: "GET", path: "/api/v1/orders/{id}", id: "GetOrder", summary: "Get an order", tag: "Orders", scope: entity.ScopeOrdersRead,
+			data: entity.Order{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound}},
+		{method: "PATCH", path: "/api/v1/orders/{id}/complete", id: "CompleteOrder", summary: "Complete a pending order", tag: "Orders", scope: entity.ScopeOrdersWrite,
+			data: entity.Order{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict}},
+		{method: "PATCH", path: "/api/v1/orders/{id}/cancel", id: "CancelOrder", summary: "Cancel an order and release its stock", tag: "Orders", scope: entity.ScopeOrdersWrite,
+			data: entity.Order{}, errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict}},
+	}
+
+	// The legacy routes share handlers with their /api/v1 equivalents
+	legacy := map[string]bool{"POST /products": true, "GET /products": true, "GET /products/{id}": true, "POST /orders": true}
+	for _, r := range routes {
+		if path := strings.TrimPrefix(r.path, "/api/v1"); legacy[r.method+" "+path] {
+			r.path, r.id, r.deprecated = path, "Legacy"+r.id, true
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// pathParam matches the parameters of a path template.
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// apiSpec describes every route SetupRoutes registers. Bodies are generated
+// from the request and entity types the handlers decode and encode.
+func apiSpec() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Product Order System",
+		Version:     "1.0.0",
+		Description: "Products, stock, warehouses and orders. Errors are RFC 7807 problem details.",
+	})
+	problem := b.Schema(handler.Problem{})
+
+	// API keys and JWTs are both accepted as bearer credentials; API keys may
+	// also be sent in their own header
+	b.AddSecurityScheme("apiKey", &openapi.SecurityScheme{
+		Type:        "apiKey",
+		Name:        middleware.APIKeyHeader,
+		In:          "header",
+		Description: "An API key issued with `server apikey create`",
+	})
+	b.AddSecurityScheme("bearer", &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "An HS256 or RS256 JWT signed by a key in the configured key set, or an API key",
+	})
+
+	for _, r := range apiRoutes() {
+		op := &openapi.Operation{
+			OperationID: r.id,
+			Summary:     r.summary,
+			Tags:        []string{r.tag},
+			Parameters:  append(pathParams(r.path), r.query...),
+			Responses:   make(map[string]*openapi.Response),
+			Deprecated:  r.deprecated,
+		}
+		errStatuses := r.errors
+		if r.scope != "" {
+			op.Security = []map[string][]string{{"apiKey": {r.scope}}, {"bearer": {r.scope}}}
+			errStatuses = append([]int{fiber.StatusUnauthorized, fiber.StatusForbidden}, errStatuses...)
+		}
+
+		if r.method == fiber.MethodPost {
+			// The idempotency middleware applies to every POST
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        middleware.IdempotencyKeyHeader,
+				In:          "header",
+				Description: "Replays the recorded response when a request is retried with the same key",
+				Schema:      &openapi.Schema{Type: "string", MaxLength: intPtr(middleware.MaxIdempotencyKeyLength)},
+			})
+		}
+
+		if r.body != nil {
+			contentType := r.contentType
+			if contentType == "" {
+				contentType = fiber.MIMEApplicationJSON
+			}
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]openapi.MediaType{contentType: {Schema: b.Schema(r.body)}},
+			}
+		}
+
+		status := r.status
+		if status == 0 {
+			status = fiber.StatusOK
+		}
+		envelope := envelopeSchema(b, r)
+		op.Responses[strconv.Itoa(status)] = jsonResponse(http.StatusText(status), envelope)
+		for extraStatus, description := range r.extra {
+			op.Responses[strconv.Itoa(extraStatus)] = jsonResponse(description, envelope)
+		}
+		for _, errStatus := range append(errStatuses, fiber.StatusInternalServerError) {
+			op.Responses[strconv.Itoa(errStatus)] = &openapi.Response{
+				Description: http.StatusText(errStatus),
+				Content:     map[string]openapi.MediaType{handler.ProblemContentType: {Schema: problem}},
+			}
+		}
+
+		b.Add(r.method, r.path, op)
+	}
+
+	b.Add(fiber.MethodGet, "/health", &openapi.Operation{
+		OperationID: "HealthCheck",
+		Summary:     "Report that the service is running",
+		Tags:        []string{"Service"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("The service is healthy", openapi.Object(map[string]*openapi.Schema{
+				"status":  openapi.Type("string"),
+				"service": openapi.Type("string"),
+			})),
+		},
+	})
+	b.Add(fiber.MethodGet, "/api/v1/openapi.json", &openapi.Operation{
+		OperationID: "GetOpenAPI",
+		Summary:     "Get this OpenAPI document",
+		Tags:        []string{"Service"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("The OpenAPI document", openapi.Type("object")),
+		},
+	})
+	b.Add(fiber.MethodGet, "/api/v1/docs", &openapi.Operation{
+		OperationID: "GetDocs",
+		Summary:     "Browse this OpenAPI document",
+		Tags:        []string{"Service"},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "An HTML page rendering the OpenAPI document",
+				Content:     map[string]openapi.MediaType{fiber.MIMETextHTML: {Schema: openapi.Type("string")}},
+			},
+		},
+	})
+
+	return b.Document()
+}
+
+// envelopeSchema describes the body the handler of r responds with.
+func envelopeSchema(b *openapi.Builder, r route) *openapi.Schema {
+	properties := map[string]*openapi.Schema{
+		"message": openapi.Type("string"),
+	}
+	if r.data != nil {
+		properties["data"] = b.Schema(r.data)
+	}
+	if r.list {
+		properties["count"] = openapi.Type("integer")
+	}
+	if r.paged {
+		properties["next_cursor"] = openapi.Nullable(openapi.Type("string"))
+	}
+	return openapi.Object(properties)
+}
+
+func jsonResponse(description string, schema *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content:     map[string]openapi.MediaType{fiber.MIMEApplicationJSON: {Schema: schema}},
+	}
+}
+
+// pathParams declares the parameters of a path template. IDs are positive
+// integers; anything else is a string.
+func pathParams(path string) []openapi.Parameter {
+	var params []openapi.Parameter
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		schema := openapi.Type("string")
+		if match[1] == "id" {
+			schema = &openapi.Schema{Type: "integer", Minimum: float(1)}
+		}
+		params = append(params, openapi.Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+func pageParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		limitParam(),
+		queryParam("cursor", openapi.Type("string"), "The next_cursor of the previous page"),
+		queryParam("sort", openapi.Type("string"), "Field to sort by; prefix with - for descending order"),
+	}
+}
+
+func limitParam() openapi.Parameter {
+	return queryParam("limit", &openapi.Schema{Type: "integer", Minimum: float(1), Maximum: float(repository.MaxPageLimit)}, "Maximum number of results")
+}
+
+func queryParam(name string, schema *openapi.Schema, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func float(n float64) *float64 {
+	return &n
+}
+
+func intPtr(n int) *int {
+	return &n
+}
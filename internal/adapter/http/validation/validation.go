@@ -0,0 +1,377 @@
+// Package validation checks decoded request bodies against the validate
+// struct tags of their types and reports every violation at once.
+//
+// A validate tag is a comma-separated list of rules, checked in order; the
+// first rule a field breaks is reported and the rest are skipped:
+//
+//	required    the value is not zero; strings must not be blank
+//	omitempty   skip the remaining rules if the value is zero or nil
+//	omitnil     skip the remaining rules if the pointer is nil
+//	min=N       numbers are at least N; strings and slices have at least N elements
+//	max=N       numbers are at most N; strings and slices have at most N elements
+//	len=N       strings and slices have exactly N elements
+//	oneof=a b   the value is one of the space-separated values
+//	pattern=re  strings match the regular expression; must be the last rule
+//
+// Pointers are checked through the value they point to. Fields are named by
+// their JSON names; nested and embedded structs and slices of structs are
+// checked too, with paths such as items[0].quantity.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+)
+
+// Errors collects field violations.
+type Errors []domainerr.FieldError
+
+// Add records a violation of field.
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, domainerr.FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns the violations as one domain validation error, or nil if there
+// are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return domainerr.NewValidationError(e...)
+}
+
+// Validator checks request structs. In strict mode it also rejects JSON
+// members that do not map to a field.
+type Validator struct {
+	strict bool
+	types  sync.Map // reflect.Type -> []field
+}
+
+func New(strict bool) *Validator {
+	return &Validator{strict: strict}
+}
+
+// Struct returns the violations of the validate tags of s, a struct or a
+// pointer to one.
+func (v *Validator) Struct(s interface{}) Errors {
+	var errs Errors
+	v.checkStruct(reflect.Indirect(reflect.ValueOf(s)), "", &errs)
+	return errs
+}
+
+// UnknownFields returns a violation for every member of the JSON object body,
+// at any depth, that s has no field for. Names match case-insensitively, as
+// they do when decoding. It returns nil unless the validator is strict.
+func (v *Validator) UnknownFields(body []byte, s interface{}) Errors {
+	if !v.strict {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	var errs Errors
+	v.checkMembers(doc, reflect.TypeOf(s), "", &errs)
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// field is a struct field with its parsed rules.
+type field struct {
+	index []int
+	name  string
+	rules []rule
+}
+
+type rule struct {
+	name  string
+	param string
+	limit float64
+	re    *regexp.Regexp
+}
+
+func (v *Validator) fields(t reflect.Type) []field {
+	if cached, ok := v.types.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, ok := jsonName(sf)
+		if !ok {
+			continue
+		}
+		// Embedded structs without a JSON name contribute their exported
+		// fields directly, even when the struct type itself is unexported
+		if sf.Anonymous && name == "" && indirectType(sf.Type).Kind() == reflect.Struct {
+			for _, inner := range v.fields(indirectType(sf.Type)) {
+				inner.index = append([]int{i}, inner.index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{index: []int{i}, name: name, rules: parseRules(sf)})
+	}
+
+	v.types.Store(t, fields)
+	return fields
+}
+
+// Rule is one rule of a validate tag, such as min=1 or required.
+type Rule struct {
+	Name  string
+	Param string
+}
+
+// ParseTag splits a validate tag into its rules without checking them.
+func ParseTag(tag string) []Rule {
+	var rules []Rule
+	for tag != "" {
+		var part string
+		// A pattern may contain commas, so it takes the rest of the tag
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, Rule{Name: name, Param: param})
+	}
+	return rules
+}
+
+// parseRules reads a validate tag. Malformed tags are programming errors and
+// panic when the type is first validated.
+func parseRules(sf reflect.StructField) []rule {
+	var rules []rule
+	for _, parsed := range ParseTag(sf.Tag.Get("validate")) {
+		r := rule{name: parsed.Name, param: parsed.Param}
+		switch r.name {
+		case "required", "omitempty", "omitnil", "oneof":
+		case "min", "max", "len":
+			limit, err := strconv.ParseFloat(r.param, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validation: invalid %s limit %q on field %s", r.name, r.param, sf.Name))
+			}
+			r.limit = limit
+		case "pattern":
+			r.re = regexp.MustCompile(r.param)
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q on field %s", r.name, sf.Name))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func (v *Validator) checkStruct(sv reflect.Value, prefix string, errs *Errors) {
+	if sv.Kind() != reflect.Struct {
+		return
+	}
+	for _, f := range v.fields(sv.Type()) {
+		fv, ok := fieldByIndex(sv, f.index)
+		if !ok {
+			continue
+		}
+		path := prefix + f.name
+		if !checkRules(fv, path, f.rules, errs) {
+			continue
+		}
+		v.checkNested(fv, path, errs)
+	}
+}
+
+// checkNested descends into struct and slice-of-struct values.
+func (v *Validator) checkNested(fv reflect.Value, path string, errs *Errors) {
+	fv = reflect.Indirect(fv)
+	switch fv.Kind() {
+	case reflect.Struct:
+		v.checkStruct(fv, path+".", errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			v.checkNested(fv.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// checkRules applies rules to fv and reports whether it passed them all or
+// was skipped as empty.
+func checkRules(fv reflect.Value, path string, rules []rule, errs *Errors) bool {
+	isNil := fv.Kind() == reflect.Pointer && fv.IsNil()
+	value := reflect.Indirect(fv)
+
+	for _, r := range rules {
+		switch r.name {
+		case "omitnil":
+			if isNil {
+				return true
+			}
+		case "omitempty":
+			if isNil || value.IsZero() {
+				return true
+			}
+		case "required":
+			if isNil || value.IsZero() || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") {
+				errs.Add(path, "required", fmt.Sprintf("%s is required", path))
+				return false
+			}
+		default:
+			if isNil {
+				continue
+			}
+			if code, message, ok := checkRule(value, path, r); !ok {
+				errs.Add(path, code, message)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func checkRule(value reflect.Value, path string, r rule) (code, message string, ok bool) {
+	switch r.name {
+	case "min", "max", "len":
+		n, sized := measure(value)
+		switch {
+		case r.name == "min" && n < r.limit:
+			if sized {
+				return "min", fmt.Sprintf("%s must have at least %s %s", path, r.param, unit(value)), false
+			}
+			return "min", fmt.Sprintf("%s must be at least %s", path, r.param), false
+		case r.name == "max" && n > r.limit:
+			if sized {
+				return "max", fmt.Sprintf("%s must have at most %s %s", path, r.param, unit(value)), false
+			}
+			return "max", fmt.Sprintf("%s must be at most %s", path, r.param), false
+		case r.name == "len" && n != r.limit:
+			return "len", fmt.Sprintf("%s must have exactly %s %s", path, r.param, unit(value)), false
+		}
+	case "oneof":
+		s := fmt.Sprint(value.Interface())
+		for _, allowed := range strings.Fields(r.param) {
+			if s == allowed {
+				return "", "", true
+			}
+		}
+		return "enum", fmt.Sprintf("%s must be one of: %s", path, strings.Join(strings.Fields(r.param), ", ")), false
+	case "pattern":
+		if value.Kind() == reflect.String && !r.re.MatchString(value.String()) {
+			return "format", fmt.Sprintf("%s has an invalid format", path), false
+		}
+	}
+	return "", "", true
+}
+
+// measure returns a number's value, or the length of a string or slice and
+// true.
+func measure(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false
+	case reflect.String:
+		return float64(len([]rune(value.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true
+	}
+	return 0, false
+}
+
+func unit(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return "characters"
+	}
+	return "items"
+}
+
+func (v *Validator) checkMembers(doc interface{}, t reflect.Type, path string, errs *Errors) {
+	t = indirectType(t)
+	switch members := doc.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		known := make(map[string]reflect.Type)
+		for _, f := range v.fields(t) {
+			known[strings.ToLower(f.name)] = t.FieldByIndex(f.index).Type
+		}
+		for name, value := range members {
+			ft, ok := known[strings.ToLower(name)]
+			if !ok {
+				errs.Add(join(path, name), "unknown", fmt.Sprintf("Unknown field %q", join(path, name)))
+				continue
+			}
+			v.checkMembers(value, ft, join(path, name), errs)
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, elem := range members {
+			v.checkMembers(elem, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonName returns the field's JSON name, empty if it has none, and false if
+// the field is not encoded.
+func jsonName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex that reports a nil embedded
+// pointer instead of panicking.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
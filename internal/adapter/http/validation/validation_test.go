@@ -0,0 +1,81 @@
+package validation_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/WaveCE29/product_order_system/internal/adapter/http/validation"
+)
+
+type testLine struct {
+	ProductID int `json:"product_id" validate:"required,min=1"`
+	Quantity  int `json:"quantity" validate:"required,min=1"`
+}
+
+type testLimits struct {
+	Max *int `json:"max" validate:"omitnil,min=1"`
+}
+
+type testRequest struct {
+	Name   string     `json:"name" validate:"required,max=5"`
+	Code   string     `json:"code" validate:"omitempty,pattern=^[A-Z]{2,3}$"`
+	Status string     `json:"status" validate:"omitempty,oneof=open closed"`
+	Lines  []testLine `json:"lines" validate:"required"`
+	testLimits
+}
+
+func TestStructReportsEveryViolation(t *testing.T) {
+	zero := 0
+	req := testRequest{
+		Name:       "toolong",
+		Code:       "abc",
+		Status:     "gone",
+		Lines:      []testLine{{ProductID: 1, Quantity: 1}, {ProductID: -1}},
+		testLimits: testLimits{Max: &zero},
+	}
+
+	var got []string
+	for _, e := range validation.New(false).Struct(&req) {
+		got = append(got, e.Field+":"+e.Code)
+	}
+
+	want := []string{
+		"name:max",
+		"code:format",
+		"status:enum",
+		"lines[1].product_id:min",
+		"lines[1].quantity:required",
+		"max:min",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+}
+
+func TestStructSkipsOptionalFields(t *testing.T) {
+	req := testRequest{Name: "ok", Lines: []testLine{{ProductID: 1, Quantity: 2}}}
+
+	if errs := validation.New(false).Struct(&req); len(errs) != 0 {
+		t.Errorf("unexpected violations: %v", errs)
+	}
+	if err := validation.New(false).Struct(&req).Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	body := []byte(`{"NAME":"ok","extra":1,"lines":[{"product_id":1,"qty":2}],"max":3}`)
+
+	if errs := validation.New(false).UnknownFields(body, &testRequest{}); errs != nil {
+		t.Errorf("non-strict validator reported %v", errs)
+	}
+
+	var got []string
+	for _, e := range validation.New(true).UnknownFields(body, &testRequest{}) {
+		got = append(got, e.Field+":"+e.Code)
+	}
+	want := []string{"extra:unknown", "lines[0].qty:unknown"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unknown fields = %v, want %v", got, want)
+	}
+}
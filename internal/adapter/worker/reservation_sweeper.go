@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+// ReservationSweeper periodically cancels pending orders whose stock
+// reservation has expired, returning the held stock to inventory.
+type ReservationSweeper struct {
+	orderUseCase input.OrderUseCase
+	interval     time.Duration
+	logger       logger.Logger
+}
+
+func NewReservationSweeper(orderUseCase input.OrderUseCase, interval time.Duration, logger logger.Logger) *ReservationSweeper {
+	return &ReservationSweeper{
+		orderUseCase: orderUseCase,
+		interval:     interval,
+		logger:       logger,
+	}
+}
+
+// Run sweeps once straight away and then every interval until ctx is
+// cancelled. A sweep interrupted by cancellation rolls back the order it was
+// expiring, which the next run picks up again. The sweeper acts for the
+// system rather than any user.
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ctx = authz.AsSystem(ctx)
+	s.logger.Info("Reservation sweeper started", "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Reservation sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReservationSweeper) sweep(ctx context.Context) {
+	expired, err := s.orderUseCase.ExpireReservations(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Failed to expire reservations", "error", err)
+		}
+		return
+	}
+
+	if expired > 0 {
+		s.logger.Info("Expired order reservations", "orders", expired)
+	}
+}
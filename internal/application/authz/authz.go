@@ -0,0 +1,114 @@
+// Package authz decides what the caller of a use case may do. Transports put
+// the authenticated principal in the context handed to a use case, which asks
+// the Policy before acting, so every transport enforces the same rules.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+type principalKey struct{}
+
+type systemKey struct{}
+
+// WithPrincipal returns a context acting for principal.
+func WithPrincipal(ctx context.Context, principal *entity.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal ctx acts for, if any.
+func PrincipalFrom(ctx context.Context) (*entity.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*entity.Principal)
+	return principal, ok
+}
+
+// AsSystem returns a context acting for the service itself, which holds every
+// permission: background workers, the command line, and every request when
+// authentication is disabled.
+func AsSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// IsSystem reports whether ctx acts for the service itself.
+func IsSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemKey{}).(bool)
+	return system
+}
+
+// Actor names the caller in audit records such as the stock ledger: the
+// principal's subject, or the system.
+func Actor(ctx context.Context) string {
+	if principal, ok := PrincipalFrom(ctx); ok {
+		return principal.Subject
+	}
+	return entity.ActorSystem
+}
+
+// Policy checks the caller's permissions. A principal holds the permissions
+// of its subject's roles, or of the customer role if it has none. Contexts
+// acting for neither a principal nor the system are refused.
+type Policy interface {
+	// Require fails with ErrPermissionDenied unless the caller holds
+	// permission.
+	Require(ctx context.Context, permission string) error
+	// Has reports whether the caller holds permission.
+	Has(ctx context.Context, permission string) (bool, error)
+}
+
+type policy struct {
+	roleRepo repository.RoleRepository
+	logger   logger.Logger
+}
+
+func NewPolicy(roleRepo repository.RoleRepository, logger logger.Logger) Policy {
+	return &policy{
+		roleRepo: roleRepo,
+		logger:   logger,
+	}
+}
+
+// Require implements Policy.
+func (p *policy) Require(ctx context.Context, permission string) error {
+	ok, err := p.Has(ctx, permission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		principal, _ := PrincipalFrom(ctx)
+		p.logger.Warn("Permission denied", "subject", principal.Subject, "permission", permission)
+		return domainerr.ErrPermissionDenied
+	}
+	return nil
+}
+
+// Has implements Policy.
+func (p *policy) Has(ctx context.Context, permission string) (bool, error) {
+	if IsSystem(ctx) {
+		return true, nil
+	}
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return false, domainerr.ErrUnauthenticated
+	}
+
+	roles, err := p.roleRepo.GetSubjectRoles(ctx, principal.Subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to get roles: %w", err)
+	}
+	if len(roles) == 0 {
+		roles = []string{entity.RoleCustomer}
+	}
+
+	permissions, err := p.roleRepo.GetPermissions(ctx, roles...)
+	if err != nil {
+		return false, fmt.Errorf("failed to get permissions: %w", err)
+	}
+	return slices.Contains(permissions, permission), nil
+}
@@ -0,0 +1,26 @@
+package input
+
+import (
+	"context"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type APIKeyUseCase interface {
+	// CreateAPIKey issues a new key and returns it with its secret, which is
+	// not stored and cannot be shown again.
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*entity.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	// AuthenticateAPIKey returns the principal an active key was issued to.
+	AuthenticateAPIKey(ctx context.Context, secret string) (*entity.Principal, error)
+}
+
+// CreateAPIKeyRequest describes a key to issue. A zero TTL never expires.
+type CreateAPIKeyRequest struct {
+	Name    string
+	Subject string
+	Scopes  []string
+	TTL     time.Duration
+}
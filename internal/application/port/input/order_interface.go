@@ -2,17 +2,65 @@ package input
 
 import (
 	"context"
+	"time"
 
 	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 )
 
 type OrderUseCase interface {
-	CreateOrder(ctx context.Context, req CreateOrderRequest) (*entity.Order, error)
+	// CreateOrder reports replayed=true when the idempotency key matched an
+	// order created by an earlier identical request.
+	CreateOrder(ctx context.Context, req CreateOrderRequest) (order *entity.Order, replayed bool, err error)
+	CompleteOrder(ctx context.Context, id int) (*entity.Order, error)
+	CancelOrder(ctx context.Context, id int) (*entity.Order, error)
+	GetOrder(ctx context.Context, id int) (*entity.Order, error)
+	ListOrders(ctx context.Context, req ListOrdersRequest) ([]*entity.Order, string, error)
+	// ExpireReservations cancels pending orders whose stock reservation has
+	// lapsed, releasing the held stock, and reports how many it cancelled.
+	ExpireReservations(ctx context.Context) (int, error)
+	BackorderFiller
 }
 
+// BackorderFiller allocates stock that has become available to queued
+// backorders.
+type BackorderFiller interface {
+	// FillBackorders moves the product's backorders to pending, oldest first,
+	// while each can be allocated in full, and returns the orders it moved.
+	// It requires the orders:manage permission.
+	FillBackorders(ctx context.Context, productID int) ([]*entity.Order, error)
+}
+
+// CreateOrderRequest accepts either a list of items or, for older clients, a
+// single product_id and quantity. WarehouseID optionally names the warehouse
+// to ship from; every allocation strategy uses it for a line it can fulfil.
 type CreateOrderRequest struct {
-	ProductID      int    `json:"product_id" validate:"required"`
-	UserID         string `json:"user_id" validate:"required"`
-	Quantity       int    `json:"quantity" validate:"required"`
-	IdempotencyKey string `json:"idempotency_key" validate:"required"`
+	ProductID      int                `json:"product_id,omitempty" validate:"omitempty,min=1"`
+	UserID         string             `json:"user_id" validate:"required"`
+	Quantity       int                `json:"quantity,omitempty" validate:"omitempty,min=1"`
+	Items          []OrderItemRequest `json:"items,omitempty"`
+	WarehouseID    int                `json:"warehouse_id,omitempty" validate:"omitempty,min=1"`
+	IdempotencyKey string             `json:"idempotency_key"`
+}
+
+type OrderItemRequest struct {
+	ProductID int `json:"product_id" validate:"required,min=1"`
+	Quantity  int `json:"quantity" validate:"required,min=1"`
+}
+
+// Lines returns the requested order lines, treating the single-product
+// fields as a one-line order when no items are given.
+func (r CreateOrderRequest) Lines() []OrderItemRequest {
+	if len(r.Items) > 0 {
+		return r.Items
+	}
+	return []OrderItemRequest{{ProductID: r.ProductID, Quantity: r.Quantity}}
+}
+
+type ListOrdersRequest struct {
+	UserID      string
+	ProductID   int
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Page        PageRequest
 }
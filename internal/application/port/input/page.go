@@ -0,0 +1,8 @@
+package input
+
+// PageRequest selects one page of a cursor-paginated listing.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   string
+}
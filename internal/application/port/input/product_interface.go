@@ -9,10 +9,83 @@ import (
 type ProductUseCase interface {
 	CreateProduct(ctx context.Context, req CreateProductRequest) (*entity.Product, error)
 	GetProduct(ctx context.Context, id int) (*entity.Product, error)
-	GetAllProduct(ctx context.Context) ([]*entity.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*entity.Product, error)
+	GetAllProduct(ctx context.Context, page PageRequest) ([]*entity.Product, string, error)
+	ListLowStockProducts(ctx context.Context, page PageRequest) ([]*entity.Product, string, error)
+	SearchProducts(ctx context.Context, req SearchProductsRequest) ([]*entity.ProductSearchResult, error)
+	UpdateProduct(ctx context.Context, id int, req UpdateProductRequest) (*entity.Product, error)
+	PatchProduct(ctx context.Context, id int, req PatchProductRequest) (*entity.Product, error)
+	DeleteProduct(ctx context.Context, id int) error
 }
 
+// SearchProductsRequest is a full-text query with offset paging; ranked
+// results have no stable cursor.
+type SearchProductsRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// Price is in minor units of Currency; an empty Currency means
+// entity.DefaultCurrency. A nil ReorderThreshold leaves the product
+// unmonitored for low stock, and a nil MaxBackorderDepth puts no limit on its
+// backorders. OrderQuantityRules are optional and checked by the use case.
 type CreateProductRequest struct {
-	Name  string `json:"name" validate:"required"`
-	Stock int    `json:"stock" validate:"required"`
+	SKU               string `json:"sku" validate:"omitempty,max=64,pattern=^[A-Za-z0-9._-]+$"`
+	Name              string `json:"name" validate:"required"`
+	Description       string `json:"description"`
+	Category          string `json:"category"`
+	Barcode           string `json:"barcode" validate:"omitempty,pattern=^([0-9]{8}|[0-9]{12,14})$"`
+	Stock             int    `json:"stock" validate:"min=0"`
+	Price             int64  `json:"price" validate:"min=0"`
+	Currency          string `json:"currency" validate:"omitempty,pattern=^[A-Z]{3}$"`
+	ReorderThreshold  *int   `json:"reorder_threshold" validate:"omitnil,min=0"`
+	AllowBackorder    bool   `json:"allow_backorder"`
+	MaxBackorderDepth *int   `json:"max_backorder_depth" validate:"omitnil,min=1"`
+	entity.OrderQuantityRules
+}
+
+// UpdateProductRequest replaces a product's details. Stock is not one of
+// them: it changes only through restocks and adjustments.
+type UpdateProductRequest struct {
+	SKU               string `json:"sku" validate:"omitempty,max=64,pattern=^[A-Za-z0-9._-]+$"`
+	Name              string `json:"name" validate:"required"`
+	Description       string `json:"description"`
+	Category          string `json:"category"`
+	Barcode           string `json:"barcode" validate:"omitempty,pattern=^([0-9]{8}|[0-9]{12,14})$"`
+	Price             int64  `json:"price" validate:"min=0"`
+	Currency          string `json:"currency" validate:"omitempty,pattern=^[A-Z]{3}$"`
+	ReorderThreshold  *int   `json:"reorder_threshold" validate:"omitnil,min=0"`
+	AllowBackorder    bool   `json:"allow_backorder"`
+	MaxBackorderDepth *int   `json:"max_backorder_depth" validate:"omitnil,min=1"`
+	entity.OrderQuantityRules
+}
+
+// PatchProductRequest carries a JSON merge patch; nil fields are left unchanged.
+// An optional field cleared with null is passed as a pointer to "", except
+// the optional numeric fields, which set the matching Clear flag.
+type PatchProductRequest struct {
+	SKU                    *string `json:"sku,omitempty" validate:"omitempty,max=64,pattern=^[A-Za-z0-9._-]+$"`
+	Name                   *string `json:"name,omitempty" validate:"omitnil,required"`
+	Description            *string `json:"description,omitempty"`
+	Category               *string `json:"category,omitempty"`
+	Barcode                *string `json:"barcode,omitempty" validate:"omitempty,pattern=^([0-9]{8}|[0-9]{12,14})$"`
+	Price                  *int64  `json:"price,omitempty" validate:"omitnil,min=0"`
+	Currency               *string `json:"currency,omitempty" validate:"omitnil,pattern=^[A-Z]{3}$"`
+	ReorderThreshold       *int    `json:"reorder_threshold,omitempty" validate:"omitnil,min=0"`
+	ClearReorderThreshold  bool    `json:"-"`
+	AllowBackorder         *bool   `json:"allow_backorder,omitempty"`
+	MaxBackorderDepth      *int    `json:"max_backorder_depth,omitempty" validate:"omitnil,min=1"`
+	ClearMaxBackorderDepth bool    `json:"-"`
+
+	MinOrderQuantity             *int `json:"min_order_quantity,omitempty" validate:"omitnil,min=1"`
+	ClearMinOrderQuantity        bool `json:"-"`
+	MaxOrderQuantity             *int `json:"max_order_quantity,omitempty" validate:"omitnil,min=1"`
+	ClearMaxOrderQuantity        bool `json:"-"`
+	OrderQuantityStep            *int `json:"order_quantity_step,omitempty" validate:"omitnil,min=1"`
+	ClearOrderQuantityStep       bool `json:"-"`
+	MaxPerUser                   *int `json:"max_per_user,omitempty" validate:"omitnil,min=1"`
+	ClearMaxPerUser              bool `json:"-"`
+	MaxPerUserWindowSeconds      *int `json:"max_per_user_window_seconds,omitempty" validate:"omitnil,min=1"`
+	ClearMaxPerUserWindowSeconds bool `json:"-"`
 }
@@ -0,0 +1,14 @@
+package input
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type RoleUseCase interface {
+	ListRoles(ctx context.Context) ([]*entity.Role, error)
+	ListRoleAssignments(ctx context.Context) ([]*entity.RoleAssignment, error)
+	AssignRole(ctx context.Context, subject, role string) error
+	UnassignRole(ctx context.Context, subject, role string) error
+}
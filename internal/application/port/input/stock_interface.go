@@ -0,0 +1,49 @@
+package input
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type StockUseCase interface {
+	// Restock and AdjustStock change stock relative to its current level and
+	// return the updated product with the recorded movement.
+	Restock(ctx context.Context, productID int, req RestockRequest) (*entity.Product, *entity.StockMovement, error)
+	AdjustStock(ctx context.Context, productID int, req StockAdjustmentRequest) (*entity.Product, *entity.StockMovement, error)
+	ListStockMovements(ctx context.Context, productID int, page PageRequest) ([]*entity.StockMovement, string, error)
+	// ListStockLevels returns the product's stock in every warehouse.
+	ListStockLevels(ctx context.Context, productID int) ([]*entity.WarehouseStock, error)
+	// TransferStock moves available stock between two warehouses and returns
+	// the recorded transfer with the product's updated stock levels.
+	TransferStock(ctx context.Context, productID int, req TransferStockRequest) (*entity.StockTransfer, []*entity.WarehouseStock, error)
+	// VerifyStockLedger returns the products whose stock disagrees with the
+	// ledger; an empty result means the ledger is consistent.
+	VerifyStockLedger(ctx context.Context) ([]entity.StockDiscrepancy, error)
+}
+
+// RestockRequest records goods received. ReferenceID may carry a purchase
+// order or delivery number. Stock goes to the primary warehouse unless
+// WarehouseID is set.
+type RestockRequest struct {
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+	Reason      string `json:"reason" validate:"required,max=500"`
+	ReferenceID string `json:"reference_id"`
+	WarehouseID int    `json:"warehouse_id" validate:"omitempty,min=1"`
+}
+
+// StockAdjustmentRequest corrects stock by a signed Delta, e.g. after a
+// stock count. It applies to the primary warehouse unless WarehouseID is set.
+type StockAdjustmentRequest struct {
+	Delta       int    `json:"delta" validate:"required"`
+	Reason      string `json:"reason" validate:"required,max=500"`
+	ReferenceID string `json:"reference_id"`
+	WarehouseID int    `json:"warehouse_id" validate:"omitempty,min=1"`
+}
+
+type TransferStockRequest struct {
+	FromWarehouseID int    `json:"from_warehouse_id" validate:"required,min=1"`
+	ToWarehouseID   int    `json:"to_warehouse_id" validate:"required,min=1"`
+	Quantity        int    `json:"quantity" validate:"required,min=1"`
+	Reason          string `json:"reason" validate:"required,max=500"`
+}
@@ -0,0 +1,18 @@
+package input
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type WarehouseUseCase interface {
+	CreateWarehouse(ctx context.Context, req CreateWarehouseRequest) (*entity.Warehouse, error)
+	GetWarehouse(ctx context.Context, id int) (*entity.Warehouse, error)
+	GetAllWarehouses(ctx context.Context) ([]*entity.Warehouse, error)
+}
+
+type CreateWarehouseRequest struct {
+	Code string `json:"code" validate:"required,max=32,pattern=^[A-Za-z0-9_-]+$"`
+	Name string `json:"name" validate:"required"`
+}
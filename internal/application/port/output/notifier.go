@@ -0,0 +1,14 @@
+package output
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+// StockAlertNotifier delivers low-stock alerts. Alerts are sent after the
+// stock change that raised them has committed; a delivery failure is reported
+// but never undoes the change.
+type StockAlertNotifier interface {
+	NotifyLowStock(ctx context.Context, alert *entity.LowStockAlert) error
+}
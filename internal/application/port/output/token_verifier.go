@@ -0,0 +1,9 @@
+package output
+
+import "github.com/WaveCE29/product_order_system/internal/domain/entity"
+
+// TokenVerifier checks a bearer token's signature and claims and returns the
+// principal it was issued to.
+type TokenVerifier interface {
+	Verify(token string) (*entity.Principal, error)
+}
@@ -2,97 +2,762 @@ package usecase
 
 import (
 	"context"
-	"database/sql"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
 	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/allocation"
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
 	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 	"github.com/WaveCE29/product_order_system/internal/domain/repository"
 	"github.com/WaveCE29/product_order_system/pkg/logger"
 )
 
+// expireBatchSize bounds how many expired orders are cancelled per query.
+const expireBatchSize = 100
+
 type orderUseCase struct {
-	orderRepo   repository.OrderRepository
-	productRepo repository.ProductRepository
-	logger      logger.Logger
+	orderRepo      repository.OrderRepository
+	productRepo    repository.ProductRepository
+	warehouseRepo  repository.WarehouseRepository
+	txManager      repository.TransactionManager
+	allocator      allocation.Strategy
+	reservationTTL time.Duration
+	notifier       output.StockAlertNotifier
+	policy         authz.Policy
+	logger         logger.Logger
 }
 
 // CreateOrder implements input.OrderUseCase.
-func (o *orderUseCase) CreateOrder(ctx context.Context, req input.CreateOrderRequest) (*entity.Order, error) {
+// The order is checked and its stock reserved in one transaction, so it is
+// placed in full or not at all; when stock is short and the products allow
+// it, the order is queued as a backorder instead. Placing an order for
+// another user takes the orders:manage permission.
+func (o *orderUseCase) CreateOrder(ctx context.Context, req input.CreateOrderRequest) (*entity.Order, bool, error) {
+	if err := o.policy.Require(ctx, entity.PermOrdersCreate); err != nil {
+		return nil, false, err
+	}
+	if principal, ok := authz.PrincipalFrom(ctx); ok && req.UserID != principal.Subject {
+		if err := o.policy.Require(ctx, entity.PermOrdersManage); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := checkDistinctProducts(req.Lines()); err != nil {
+		o.logger.Warn("Order lists a product more than once", "user_id", req.UserID)
+		return nil, false, err
+	}
+	lines := sortedLines(req.Lines())
+
 	o.logger.Info("Creating new order",
-		"product_id", req.ProductID,
 		"user_id", req.UserID,
-		"quantity", req.Quantity,
+		"lines", len(lines),
 		"idempotency_key", req.IdempotencyKey)
 
-	// Check for existing order with same idempotency key
-	existingOrder, err := o.orderRepo.GetByIdempotencyKey(ctx, req.IdempotencyKey)
-	if err != nil && err != sql.ErrNoRows {
-		o.logger.Error("Failed to check idempotency key", "error", err)
-		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+	fingerprint, err := orderFingerprint(req.UserID, req.WarehouseID, lines)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		order    *entity.Order
+		replayed bool
+		alerts   lowStockAlerts
+	)
+	err = o.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Check for existing order with same idempotency key for this user
+		var existingOrder *entity.Order
+		if req.IdempotencyKey != "" {
+			existingOrder, err = o.orderRepo.GetByIdempotencyKey(ctx, req.UserID, req.IdempotencyKey)
+			if err != nil && !errors.Is(err, domainerr.ErrOrderNotFound) {
+				o.logger.Error("Failed to check idempotency key", "error", err)
+				return fmt.Errorf("failed to check idempotency key: %w", err)
+			}
+		}
+		if existingOrder != nil {
+			// Orders created before fingerprints were recorded cannot be compared
+			if existingOrder.RequestFingerprint != "" && existingOrder.RequestFingerprint != fingerprint {
+				o.logger.Warn("Idempotency key reused with a different payload",
+					"order_id", existingOrder.ID,
+					"user_id", req.UserID,
+					"idempotency_key", req.IdempotencyKey)
+				return domainerr.ErrIdempotencyKeyReused.Withf("idempotency key %q was already used for order %d with a different request", req.IdempotencyKey, existingOrder.ID)
+			}
+
+			o.logger.Info("Order already exists with idempotency key", "order_id", existingOrder.ID)
+			order = existingOrder
+			replayed = true
+			return nil
+		}
+
+		if req.WarehouseID != 0 {
+			if _, err := o.warehouseRepo.GetByID(ctx, req.WarehouseID); err != nil {
+				o.logger.Warn("Preferred warehouse not found", "warehouse_id", req.WarehouseID)
+				return fmt.Errorf("failed to get warehouse: %w", err)
+			}
+		}
+
+		now := time.Now()
+		reservedUntil := now.Add(o.reservationTTL)
+		newOrder := &entity.Order{
+			UserID:             req.UserID,
+			Status:             entity.OrderStatusPending,
+			IdempotencyKey:     req.IdempotencyKey,
+			RequestFingerprint: fingerprint,
+			CreatedAt:          now,
+			ReservedUntil:      &reservedUntil,
+			Items:              make([]*entity.OrderItem, 0, len(lines)),
+		}
+		// Available stock of each product before this order, to detect threshold crossings
+		previousAvailable := make(map[int]int, len(lines))
+		backorder := false
+
+		for _, line := range lines {
+			// Get product to check stock
+			product, err := o.productRepo.GetbyID(ctx, line.ProductID)
+			if err != nil {
+				if errors.Is(err, domainerr.ErrProductNotFound) {
+					o.logger.Warn("Product not found", "product_id", line.ProductID)
+				} else {
+					o.logger.Error("Failed to get product", "product_id", line.ProductID, "error", err)
+				}
+				return fmt.Errorf("failed to get product: %w", err)
+			}
+
+			if err := o.checkQuantityRules(ctx, req.UserID, product, line.Quantity, now); err != nil {
+				return err
+			}
+
+			// Backorders already waiting for the product are served first, so a
+			// new order joins the end of the queue
+			queued := 0
+			if product.AllowBackorder {
+				queued, err = o.orderRepo.CountBackorders(ctx, product.ID)
+				if err != nil {
+					o.logger.Error("Failed to count backorders", "product_id", product.ID, "error", err)
+					return fmt.Errorf("failed to count backorders: %w", err)
+				}
+			}
+
+			// Check if enough stock available
+			short := queued > 0 || product.Available < line.Quantity
+			if short && !product.AllowBackorder {
+				o.logger.Warn("Insufficient stock",
+					"product_id", line.ProductID,
+					"available", product.Available,
+					"requested", line.Quantity)
+				return domainerr.ErrInsufficientStock.Withf("insufficient stock for product %d: available %d, requested %d", line.ProductID, product.Available, line.Quantity)
+			}
+
+			if len(newOrder.Items) > 0 && newOrder.Items[0].Currency != product.Currency {
+				o.logger.Warn("Order mixes currencies",
+					"product_id", line.ProductID,
+					"currency", product.Currency,
+					"order_currency", newOrder.Items[0].Currency)
+				return domainerr.ErrCurrencyMismatch.Withf("product %d is priced in %s but the order is in %s", line.ProductID, product.Currency, newOrder.Items[0].Currency)
+			}
+
+			var warehouseID int
+			if !short {
+				warehouseID, err = o.allocate(ctx, line, req.WarehouseID)
+				// Stock split across warehouses can still be backordered
+				if errors.Is(err, domainerr.ErrInsufficientStock) && product.AllowBackorder {
+					short = true
+				} else if err != nil {
+					return err
+				}
+			}
+
+			if short {
+				if product.BackorderQueueFull(queued) {
+					o.logger.Warn("Backorder queue full", "product_id", product.ID, "queued", queued)
+					return domainerr.ErrBackorderQueueFull.Withf("backorder queue for product %d is full: %d orders waiting", product.ID, queued)
+				}
+				backorder = true
+			}
+
+			if _, ok := previousAvailable[product.ID]; !ok {
+				previousAvailable[product.ID] = product.Available
+			}
+
+			// Snapshot the current price so later price changes leave the order untouched
+			newOrder.Items = append(newOrder.Items, &entity.OrderItem{
+				ProductID:   line.ProductID,
+				WarehouseID: warehouseID,
+				Quantity:    line.Quantity,
+				UnitPrice:   product.Price,
+				Currency:    product.Currency,
+			})
+		}
+		newOrder.CalculateTotals()
+
+		// A backorder holds no stock, so none of its lines is allocated yet
+		if backorder {
+			newOrder.Status = entity.OrderStatusBackordered
+			newOrder.ReservedUntil = nil
+			for _, item := range newOrder.Items {
+				item.WarehouseID = 0
+			}
+		}
+
+		if err := o.orderRepo.Create(ctx, newOrder); err != nil {
+			o.logger.Error("Failed to create order", "error", err)
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+
+		if backorder {
+			o.logger.Info("Order backordered",
+				"order_id", newOrder.ID,
+				"lines", len(newOrder.Items),
+				"total", newOrder.Total,
+				"currency", newOrder.Currency)
+
+			order = newOrder
+			return nil
+		}
+
+		// The conditional reservation is the final guard against overselling
+		for _, item := range newOrder.Items {
+			if err := o.productRepo.ReserveStock(ctx, item.ProductID, item.WarehouseID, item.Quantity); err != nil {
+				o.logger.Error("Failed to reserve product stock", "product_id", item.ProductID, "error", err)
+				return fmt.Errorf("failed to reserve product stock: %w", err)
+			}
+		}
+
+		if err := o.checkLowStock(ctx, previousAvailable, &alerts); err != nil {
+			return err
+		}
+
+		o.logger.Info("Order created successfully",
+			"order_id", newOrder.ID,
+			"lines", len(newOrder.Items),
+			"total", newOrder.Total,
+			"currency", newOrder.Currency,
+			"reserved_until", reservedUntil)
+
+		order = newOrder
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
 	}
-	if existingOrder != nil {
-		o.logger.Info("Order already exists with idempotency key", "order_id", existingOrder.ID)
-		return existingOrder, nil
+
+	alerts.send(ctx, o.notifier, o.logger)
+	return order, replayed, nil
+
+}
+
+// FillBackorders implements input.OrderUseCase.
+// Backorders are filled strictly in queue order: the first one that cannot be
+// allocated in full ends the pass, so later orders never overtake it. Filled
+// orders become pending and hold their stock for the configured TTL. Filling
+// takes the orders:manage permission; the use cases fill backorders after
+// their own changes as the system.
+func (o *orderUseCase) FillBackorders(ctx context.Context, productID int) ([]*entity.Order, error) {
+	if err := o.policy.Require(ctx, entity.PermOrdersManage); err != nil {
+		return nil, err
 	}
 
-	// Get product to check stock
-	product, err := o.productRepo.GetbyID(ctx, req.ProductID)
+	var (
+		filled []*entity.Order
+		alerts lowStockAlerts
+	)
+	err := o.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		backorders, err := o.orderRepo.ListBackorders(ctx, productID)
+		if err != nil {
+			o.logger.Error("Failed to list backorders", "product_id", productID, "error", err)
+			return fmt.Errorf("failed to list backorders: %w", err)
+		}
+
+		previousAvailable := make(map[int]int)
+		for _, order := range backorders {
+			ok, err := o.fillBackorder(ctx, order, previousAvailable)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				o.logger.Info("Backorder cannot be filled yet", "order_id", order.ID, "product_id", productID)
+				break
+			}
+			filled = append(filled, order)
+		}
+
+		return o.checkLowStock(ctx, previousAvailable, &alerts)
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			o.logger.Error("Product not found", "product_id", req.ProductID)
-			return nil, fmt.Errorf("product with id %d not found", req.ProductID)
+		return nil, err
+	}
+
+	alerts.send(ctx, o.notifier, o.logger)
+	for _, order := range filled {
+		o.logger.Info("Backorder filled", "order_id", order.ID, "reserved_until", *order.ReservedUntil)
+	}
+	return filled, nil
+}
+
+// fillBackorder allocates and reserves every line of a backorder and moves it
+// to pending. It reports false, leaving the order untouched, if some line
+// cannot be allocated yet; as each product appears on only one line, checking
+// every line before reserving any is enough. previousAvailable collects each
+// product's available stock before it was reserved.
+func (o *orderUseCase) fillBackorder(ctx context.Context, order *entity.Order, previousAvailable map[int]int) (bool, error) {
+	warehouseIDs := make([]int, len(order.Items))
+	for i, item := range order.Items {
+		product, err := o.productRepo.GetbyID(ctx, item.ProductID)
+		if err != nil {
+			o.logger.Error("Failed to get product", "product_id", item.ProductID, "error", err)
+			return false, fmt.Errorf("failed to get product: %w", err)
+		}
+		if product.Available < item.Quantity {
+			return false, nil
+		}
+
+		warehouseIDs[i], err = o.allocate(ctx, input.OrderItemRequest{ProductID: item.ProductID, Quantity: item.Quantity}, 0)
+		if errors.Is(err, domainerr.ErrInsufficientStock) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if _, ok := previousAvailable[product.ID]; !ok {
+			previousAvailable[product.ID] = product.Available
 		}
-		o.logger.Error("Failed to get product", "product_id", req.ProductID, "error", err)
-		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	// Check if enough stock available
-	if product.Stock < req.Quantity {
-		o.logger.Warn("Insufficient stock",
-			"product_id", req.ProductID,
-			"available", product.Stock,
-			"requested", req.Quantity)
-		return nil, fmt.Errorf("insufficient stock: available %d, requested %d", product.Stock, req.Quantity)
+	for i, item := range order.Items {
+		if err := o.productRepo.ReserveStock(ctx, item.ProductID, warehouseIDs[i], item.Quantity); err != nil {
+			o.logger.Error("Failed to reserve product stock", "product_id", item.ProductID, "error", err)
+			return false, fmt.Errorf("failed to reserve product stock: %w", err)
+		}
+		item.WarehouseID = warehouseIDs[i]
 	}
 
-	// Create order
-	order := &entity.Order{
-		ProductID:      req.ProductID,
-		UserID:         req.UserID,
-		Quantity:       req.Quantity,
-		Status:         entity.OrderStatusPending,
-		IdempotencyKey: req.IdempotencyKey,
-		CreatedAt:      time.Now(),
+	if err := order.TransitionTo(entity.OrderStatusPending); err != nil {
+		return false, err
 	}
+	reservedUntil := time.Now().Add(o.reservationTTL)
+	order.ReservedUntil = &reservedUntil
 
-	if err := o.orderRepo.Create(ctx, order); err != nil {
-		o.logger.Error("Failed to create order", "error", err)
-		return nil, fmt.Errorf("failed to create order: %w", err)
+	if err := o.orderRepo.PromoteBackorder(ctx, order); err != nil {
+		o.logger.Error("Failed to promote backorder", "order_id", order.ID, "error", err)
+		return false, fmt.Errorf("failed to promote backorder: %w", err)
 	}
+	return true, nil
+}
 
-	// Update product stock
-	newStock := product.Stock - req.Quantity
-	if err := o.productRepo.UpdateStock(ctx, req.ProductID, newStock); err != nil {
-		o.logger.Error("Failed to update product stock", "product_id", req.ProductID, "error", err)
-		return nil, fmt.Errorf("failed to update product stock: %w", err)
+// fillBackordersFor offers stock freed by a change to an order to the
+// backorders of each of its products.
+func (o *orderUseCase) fillBackordersFor(ctx context.Context, order *entity.Order) {
+	for _, item := range order.Items {
+		fillBackorders(ctx, o, o.logger, item.ProductID)
 	}
+}
 
-	o.logger.Info("Order created successfully",
-		"order_id", order.ID,
-		"product_id", req.ProductID,
-		"new_stock", newStock)
+// fillBackorders offers stock that a change made available to the product's
+// waiting backorders and reports how many were filled. It runs after that
+// change has committed, so a failure is only logged and leaves the backorders
+// queued for the next change. The caller was authorized for the change, not
+// for managing other users' orders, so the backorders are filled as the
+// system.
+func fillBackorders(ctx context.Context, filler input.BackorderFiller, logger logger.Logger, productID int) int {
+	filled, err := filler.FillBackorders(authz.AsSystem(ctx), productID)
+	if err != nil {
+		logger.Error("Failed to fill backorders", "product_id", productID, "error", err)
+		return 0
+	}
+	return len(filled)
+}
+
+// checkLowStock reloads each product in previousAvailable and records an
+// alert for those whose reservations took them to their reorder threshold.
+func (o *orderUseCase) checkLowStock(ctx context.Context, previousAvailable map[int]int, alerts *lowStockAlerts) error {
+	productIDs := make([]int, 0, len(previousAvailable))
+	for productID := range previousAvailable {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	for _, productID := range productIDs {
+		product, err := o.productRepo.GetbyID(ctx, productID)
+		if err != nil {
+			o.logger.Error("Failed to get product", "product_id", productID, "error", err)
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		alerts.check(product, previousAvailable[productID])
+	}
+	return nil
+}
+
+// allocate picks the warehouse a line is fulfilled from.
+func (o *orderUseCase) allocate(ctx context.Context, line input.OrderItemRequest, preferredWarehouseID int) (int, error) {
+	levels, err := o.warehouseRepo.ListStock(ctx, line.ProductID)
+	if err != nil {
+		o.logger.Error("Failed to list stock levels", "product_id", line.ProductID, "error", err)
+		return 0, fmt.Errorf("failed to list stock levels: %w", err)
+	}
+
+	warehouseID, ok := o.allocator.Allocate(allocation.Line{
+		ProductID:            line.ProductID,
+		Quantity:             line.Quantity,
+		PreferredWarehouseID: preferredWarehouseID,
+	}, levels)
+	if !ok {
+		o.logger.Warn("No warehouse can fulfil order line",
+			"product_id", line.ProductID,
+			"requested", line.Quantity)
+		return 0, domainerr.ErrInsufficientStock.Withf("insufficient stock for product %d: no single warehouse has %d available", line.ProductID, line.Quantity)
+	}
+
+	return warehouseID, nil
+}
+
+// checkQuantityRules checks the quantity of product on a new order by userID
+// against the product's order quantity rules, including what the user has
+// ordered within the product's rolling window.
+func (o *orderUseCase) checkQuantityRules(ctx context.Context, userID string, product *entity.Product, quantity int, now time.Time) error {
+	if err := product.CheckQuantity(product.ID, quantity); err != nil {
+		o.logger.Warn("Order quantity rule violated", "product_id", product.ID, "quantity", quantity, "error", err)
+		return err
+	}
+
+	window, ok := product.PerUserWindow()
+	if !ok {
+		return nil
+	}
+	ordered, err := o.orderRepo.SumUserQuantity(ctx, userID, product.ID, now.Add(-window))
+	if err != nil {
+		o.logger.Error("Failed to sum user order quantity", "product_id", product.ID, "user_id", userID, "error", err)
+		return fmt.Errorf("failed to sum user order quantity: %w", err)
+	}
+	if ordered+quantity > *product.MaxPerUser {
+		o.logger.Warn("Per-user purchase limit exceeded",
+			"product_id", product.ID,
+			"user_id", userID,
+			"ordered", ordered,
+			"requested", quantity,
+			"limit", *product.MaxPerUser)
+		return domainerr.ErrPurchaseLimit.Withf("user %s may order at most %d of product %d per %s: %d already ordered, requested %d", userID, *product.MaxPerUser, product.ID, window, ordered, quantity)
+	}
+	return nil
+}
+
+// checkDistinctProducts rejects an order naming a product on more than one
+// line. Quantity rules, allocation and backorder filling all take a product's
+// line to be its whole quantity in the order.
+func checkDistinctProducts(lines []input.OrderItemRequest) error {
+	seen := make(map[int]bool, len(lines))
+	for i, line := range lines {
+		if seen[line.ProductID] {
+			return domainerr.Invalid(fmt.Sprintf("items[%d].product_id", i), "duplicate", "Each product may appear only once per order")
+		}
+		seen[line.ProductID] = true
+	}
+	return nil
+}
+
+// sortedLines returns the lines ordered by product ID so that the same set of
+// lines always fingerprints and locks in the same order.
+func sortedLines(lines []input.OrderItemRequest) []input.OrderItemRequest {
+	sorted := append([]input.OrderItemRequest(nil), lines...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ProductID < sorted[j].ProductID
+	})
+	return sorted
+}
+
+// orderFingerprint hashes the fields that define an order request, excluding
+// the idempotency key itself. One-line orders keep the original single-product
+// encoding, and an unset warehouse is left out, so keys recorded by earlier
+// versions still match.
+func orderFingerprint(userID string, warehouseID int, lines []input.OrderItemRequest) (string, error) {
+	var v interface{}
+	if len(lines) == 1 {
+		v = struct {
+			ProductID   int    `json:"product_id"`
+			UserID      string `json:"user_id"`
+			Quantity    int    `json:"quantity"`
+			WarehouseID int    `json:"warehouse_id,omitempty"`
+		}{lines[0].ProductID, userID, lines[0].Quantity, warehouseID}
+	} else {
+		v = struct {
+			UserID      string                   `json:"user_id"`
+			Items       []input.OrderItemRequest `json:"items"`
+			WarehouseID int                      `json:"warehouse_id,omitempty"`
+		}{userID, lines, warehouseID}
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint order request: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CompleteOrder implements input.OrderUseCase.
+// The held stock is taken out of inventory; an order whose reservation has
+// lapsed can no longer be completed.
+func (o *orderUseCase) CompleteOrder(ctx context.Context, id int) (*entity.Order, error) {
+	o.logger.Info("Completing order", "order_id", id)
+
+	return o.transitionOrder(ctx, id, entity.OrderStatusCompleted, "", func(ctx context.Context, order *entity.Order) error {
+		// Orders placed before reservations already consumed their stock
+		if !order.HoldsReservation() {
+			return nil
+		}
+
+		if order.ReservationExpired(time.Now()) {
+			o.logger.Warn("Order reservation expired", "order_id", order.ID, "reserved_until", *order.ReservedUntil)
+			return domainerr.ErrReservationExpired.Withf("reservation for order %d expired at %s", order.ID, order.ReservedUntil.Format(time.RFC3339))
+		}
+
+		for _, item := range order.Items {
+			change := repository.StockChange{
+				Reason:      entity.StockReasonOrder,
+				ReferenceID: strconv.Itoa(order.ID),
+				Actor:       order.UserID,
+			}
+			if err := o.productRepo.CommitReservedStock(ctx, item.ProductID, item.WarehouseID, item.Quantity, change); err != nil {
+				o.logger.Error("Failed to update product stock", "product_id", item.ProductID, "error", err)
+				return fmt.Errorf("failed to update product stock: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// CancelOrder implements input.OrderUseCase.
+// Stock the order released, and the place a cancelled backorder held in its
+// queues, go to waiting backorders. Customers may cancel their own orders.
+func (o *orderUseCase) CancelOrder(ctx context.Context, id int) (*entity.Order, error) {
+	o.logger.Info("Cancelling order", "order_id", id)
+
+	order, err := o.transitionOrder(ctx, id, entity.OrderStatusCancelled, entity.PermOrdersCancelOwn, o.releaseStock)
+	if err != nil {
+		return nil, err
+	}
 
+	o.fillBackordersFor(ctx, order)
 	return order, nil
+}
+
+// ExpireReservations implements input.OrderUseCase.
+func (o *orderUseCase) ExpireReservations(ctx context.Context) (int, error) {
+	if err := o.policy.Require(ctx, entity.PermOrdersManage); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for {
+		ids, err := o.orderRepo.ListExpiredReservations(ctx, time.Now(), expireBatchSize)
+		if err != nil {
+			o.logger.Error("Failed to list expired reservations", "error", err)
+			return expired, fmt.Errorf("failed to list expired reservations: %w", err)
+		}
+
+		for _, id := range ids {
+			o.logger.Info("Expiring order reservation", "order_id", id)
+
+			order, err := o.transitionOrder(ctx, id, entity.OrderStatusCancelled, "", o.releaseStock)
+			if errors.Is(err, domainerr.ErrInvalidTransition) {
+				// Completed or cancelled since it was listed
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			expired++
+
+			o.fillBackordersFor(ctx, order)
+		}
+
+		if len(ids) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// releaseStock returns the stock of every line of a cancelled order to its
+// product. Lines of a backorder were never allocated and hold nothing.
+func (o *orderUseCase) releaseStock(ctx context.Context, order *entity.Order) error {
+	for _, item := range order.Items {
+		if !item.Allocated() {
+			continue
+		}
+
+		if order.HoldsReservation() {
+			if err := o.productRepo.ReleaseReservedStock(ctx, item.ProductID, item.WarehouseID, item.Quantity); err != nil {
+				o.logger.Error("Failed to release reserved stock", "product_id", item.ProductID, "error", err)
+				return fmt.Errorf("failed to release reserved stock: %w", err)
+			}
+			continue
+		}
+
+		// Orders placed before reservations took their stock when created
+		change := repository.StockChange{
+			Reason:      entity.StockReasonCancellation,
+			ReferenceID: strconv.Itoa(order.ID),
+			Actor:       order.UserID,
+		}
+		if err := o.productRepo.IncrementStock(ctx, item.ProductID, item.WarehouseID, item.Quantity, change); err != nil {
+			o.logger.Error("Failed to restore product stock", "product_id", item.ProductID, "error", err)
+			return fmt.Errorf("failed to restore product stock: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetOrder implements input.OrderUseCase.
+// Customers may read their own orders only.
+func (o *orderUseCase) GetOrder(ctx context.Context, id int) (*entity.Order, error) {
+	o.logger.Info("Getting order", "id", id)
 
+	return o.loadOrder(ctx, id, entity.PermOrdersReadOwn, entity.PermOrdersReadAll)
+}
+
+// loadOrder returns order id if the caller holds the permission all, or own
+// and the order is theirs. Callers limited to their own orders are refused
+// alike whether an order is someone else's or does not exist, so they cannot
+// learn which orders exist. An empty own allows no caller without all.
+func (o *orderUseCase) loadOrder(ctx context.Context, id int, own, all string) (*entity.Order, error) {
+	anyOrder, err := o.policy.Has(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+	if !anyOrder {
+		if own == "" {
+			return nil, o.policy.Require(ctx, all)
+		}
+		if err := o.policy.Require(ctx, own); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := o.orderRepo.GetByID(ctx, id)
+	if !anyOrder {
+		principal, _ := authz.PrincipalFrom(ctx)
+		if errors.Is(err, domainerr.ErrOrderNotFound) || (err == nil && order.UserID != principal.Subject) {
+			o.logger.Warn("Refused access to order", "order_id", id, "subject", principal.Subject)
+			return nil, domainerr.ErrPermissionDenied
+		}
+	}
+	if err != nil {
+		o.logger.Error("Failed to get order", "order_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// ListOrders implements input.OrderUseCase.
+// Customers only see their own orders, and may not ask for anyone else's.
+func (o *orderUseCase) ListOrders(ctx context.Context, req input.ListOrdersRequest) ([]*entity.Order, string, error) {
+	anyOrder, err := o.policy.Has(ctx, entity.PermOrdersReadAll)
+	if err != nil {
+		return nil, "", err
+	}
+	if !anyOrder {
+		if err := o.policy.Require(ctx, entity.PermOrdersReadOwn); err != nil {
+			return nil, "", err
+		}
+		principal, _ := authz.PrincipalFrom(ctx)
+		if req.UserID != "" && req.UserID != principal.Subject {
+			return nil, "", domainerr.ErrPermissionDenied
+		}
+		req.UserID = principal.Subject
+	}
+
+	o.logger.Info("Listing orders",
+		"user_id", req.UserID,
+		"product_id", req.ProductID,
+		"status", req.Status)
+
+	filter := repository.OrderFilter{
+		UserID:      req.UserID,
+		ProductID:   req.ProductID,
+		Status:      req.Status,
+		CreatedFrom: req.CreatedFrom,
+	}
+	// CreatedTo is inclusive for callers
+	if !req.CreatedTo.IsZero() {
+		filter.CreatedBefore = req.CreatedTo.Add(time.Nanosecond)
+	}
+
+	orders, nextCursor, err := o.orderRepo.List(ctx, filter, toRepositoryPage(req.Page))
+	if err != nil {
+		o.logger.Error("Failed to list orders", "error", err)
+		return nil, "", fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	o.logger.Info("Retrieved orders", "count", len(orders))
+	return orders, nextCursor, nil
+}
+
+// transitionOrder moves an order to status inside a transaction, running
+// sideEffect (if any) as part of the same unit of work. The caller needs the
+// orders:manage permission or, for their own orders, own; see loadOrder.
+func (o *orderUseCase) transitionOrder(ctx context.Context, id int, status, own string, sideEffect func(ctx context.Context, order *entity.Order) error) (*entity.Order, error) {
+	var order *entity.Order
+	err := o.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := o.loadOrder(ctx, id, own, entity.PermOrdersManage)
+		if err != nil {
+			return err
+		}
+
+		previousStatus := existing.Status
+		if err := existing.TransitionTo(status); err != nil {
+			o.logger.Warn("Rejected order status transition",
+				"order_id", id,
+				"from", previousStatus,
+				"to", status)
+			return err
+		}
+
+		if err := o.orderRepo.UpdateStatus(ctx, id, previousStatus, status); err != nil {
+			o.logger.Error("Failed to update order status", "order_id", id, "error", err)
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+
+		if sideEffect != nil {
+			if err := sideEffect(ctx, existing); err != nil {
+				return err
+			}
+		}
+
+		order = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	o.logger.Info("Order status updated", "order_id", id, "status", status)
+	return order, nil
 }
 
-func NewOrderUseCase(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, logger logger.Logger) input.OrderUseCase {
+// NewOrderUseCase returns an order use case that allocates order lines to
+// warehouses with allocator and holds their stock for reservationTTL.
+func NewOrderUseCase(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, warehouseRepo repository.WarehouseRepository, txManager repository.TransactionManager, allocator allocation.Strategy, reservationTTL time.Duration, notifier output.StockAlertNotifier, policy authz.Policy, logger logger.Logger) input.OrderUseCase {
 	return &orderUseCase{
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-		logger:      logger,
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		warehouseRepo:  warehouseRepo,
+		txManager:      txManager,
+		allocator:      allocator,
+		reservationTTL: reservationTTL,
+		notifier:       notifier,
+		policy:         policy,
+		logger:         logger,
 	}
 
 }
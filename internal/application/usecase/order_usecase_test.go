@@ -0,0 +1,524 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/allocation"
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/testenv"
+)
+
+func TestCreateOrderConcurrentStock(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	const (
+		initialStock = 25
+		workers      = 100
+		quantity     = 1
+	)
+
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Limited", Stock: initialStock})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{
+				ProductID:      product.ID,
+				UserID:         fmt.Sprintf("user-%d", i),
+				Quantity:       quantity,
+				IdempotencyKey: fmt.Sprintf("order-%d", i),
+			})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded != initialStock/quantity {
+		t.Errorf("expected %d successful orders, got %d", initialStock/quantity, succeeded)
+	}
+
+	got, err := env.Products.GetbyID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	// Pending orders hold their stock rather than consuming it
+	if got.Stock != initialStock {
+		t.Errorf("expected stock on hand %d, got %d", initialStock, got.Stock)
+	}
+	if got.Available != 0 {
+		t.Errorf("expected final available stock 0, got %d", got.Available)
+	}
+
+	orders, err := env.Orders.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("failed to list orders: %v", err)
+	}
+	if len(orders)*quantity != got.Reserved {
+		t.Errorf("orders account for %d units but %d are reserved", len(orders)*quantity, got.Reserved)
+	}
+
+	discrepancies, err := env.Movements.Verify(ctx)
+	if err != nil {
+		t.Fatalf("failed to verify stock ledger: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("stock ledger does not match stock: %+v", discrepancies)
+	}
+}
+
+func TestOrderPermissions(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+
+	system := authz.AsSystem(context.Background())
+	as := func(subject string) context.Context {
+		return authz.WithPrincipal(context.Background(), &entity.Principal{Subject: subject})
+	}
+	alice, bob, staff := as("alice"), as("bob"), as("staff-1")
+
+	if err := env.Roles.Assign(system, &entity.RoleAssignment{Subject: "staff-1", Role: entity.RoleStaff, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to assign role: %v", err)
+	}
+
+	if _, err := env.ProductUseCase.CreateProduct(alice, input.CreateProductRequest{Name: "Widget", Stock: 10}); !errors.Is(err, domainerr.ErrPermissionDenied) {
+		t.Errorf("customer creating a product: expected ErrPermissionDenied, got %v", err)
+	}
+	if _, err := env.ProductUseCase.CreateProduct(context.Background(), input.CreateProductRequest{Name: "Widget", Stock: 10}); !errors.Is(err, domainerr.ErrUnauthenticated) {
+		t.Errorf("anonymous caller: expected ErrUnauthenticated, got %v", err)
+	}
+	product, err := env.ProductUseCase.CreateProduct(staff, input.CreateProductRequest{Name: "Widget", Stock: 10})
+	if err != nil {
+		t.Fatalf("staff failed to create product: %v", err)
+	}
+
+	if _, _, err := env.OrderUseCase.CreateOrder(alice, input.CreateOrderRequest{ProductID: product.ID, UserID: "bob", Quantity: 1}); !errors.Is(err, domainerr.ErrPermissionDenied) {
+		t.Errorf("customer ordering for another user: expected ErrPermissionDenied, got %v", err)
+	}
+	order, _, err := env.OrderUseCase.CreateOrder(alice, input.CreateOrderRequest{ProductID: product.ID, UserID: "alice", Quantity: 1})
+	if err != nil {
+		t.Fatalf("customer failed to create order: %v", err)
+	}
+
+	if _, err := env.OrderUseCase.GetOrder(alice, order.ID); err != nil {
+		t.Errorf("customer reading own order: %v", err)
+	}
+	if _, err := env.OrderUseCase.GetOrder(staff, order.ID); err != nil {
+		t.Errorf("staff reading any order: %v", err)
+	}
+
+	// A foreign order and a missing one are refused alike
+	_, foreign := env.OrderUseCase.GetOrder(bob, order.ID)
+	_, missing := env.OrderUseCase.GetOrder(bob, order.ID+1000)
+	if !errors.Is(foreign, domainerr.ErrPermissionDenied) || !errors.Is(missing, domainerr.ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied for foreign and missing orders, got %v and %v", foreign, missing)
+	}
+	if _, err := env.OrderUseCase.CancelOrder(bob, order.ID); !errors.Is(err, domainerr.ErrPermissionDenied) {
+		t.Errorf("customer cancelling a foreign order: expected ErrPermissionDenied, got %v", err)
+	}
+	if _, err := env.OrderUseCase.CompleteOrder(alice, order.ID); !errors.Is(err, domainerr.ErrPermissionDenied) {
+		t.Errorf("customer completing own order: expected ErrPermissionDenied, got %v", err)
+	}
+
+	orders, _, err := env.OrderUseCase.ListOrders(bob, input.ListOrdersRequest{})
+	if err != nil {
+		t.Fatalf("customer failed to list orders: %v", err)
+	}
+	if len(orders) != 0 {
+		t.Errorf("customer listed %d orders of other users", len(orders))
+	}
+	if _, _, err := env.OrderUseCase.ListOrders(bob, input.ListOrdersRequest{UserID: "alice"}); !errors.Is(err, domainerr.ErrPermissionDenied) {
+		t.Errorf("customer listing another user's orders: expected ErrPermissionDenied, got %v", err)
+	}
+	orders, _, err = env.OrderUseCase.ListOrders(staff, input.ListOrdersRequest{UserID: "alice"})
+	if err != nil || len(orders) != 1 {
+		t.Errorf("staff listing alice's orders: expected 1 order, got %d (%v)", len(orders), err)
+	}
+
+	if _, err := env.OrderUseCase.FillBackorders(alice, product.ID); !errors.Is(err, domainerr.ErrPermissionDenied) {
+		t.Errorf("customer filling backorders: expected ErrPermissionDenied, got %v", err)
+	}
+
+	// Stock a customer frees by cancelling still goes to other users' backorders
+	scarce, err := env.ProductUseCase.CreateProduct(staff, input.CreateProductRequest{Name: "Scarce", Stock: 1, AllowBackorder: true})
+	if err != nil {
+		t.Fatalf("staff failed to create product: %v", err)
+	}
+	held, _, err := env.OrderUseCase.CreateOrder(alice, input.CreateOrderRequest{ProductID: scarce.ID, UserID: "alice", Quantity: 1})
+	if err != nil {
+		t.Fatalf("customer failed to create order: %v", err)
+	}
+	waiting, _, err := env.OrderUseCase.CreateOrder(bob, input.CreateOrderRequest{ProductID: scarce.ID, UserID: "bob", Quantity: 1})
+	if err != nil || waiting.Status != entity.OrderStatusBackordered {
+		t.Fatalf("expected a backorder, got %v (%v)", waiting, err)
+	}
+
+	if _, err := env.OrderUseCase.CancelOrder(alice, order.ID); err != nil {
+		t.Errorf("customer cancelling own order: %v", err)
+	}
+	if _, err := env.OrderUseCase.CancelOrder(alice, held.ID); err != nil {
+		t.Fatalf("customer cancelling own order: %v", err)
+	}
+	if filled, err := env.OrderUseCase.GetOrder(bob, waiting.ID); err != nil || filled.Status != entity.OrderStatusPending {
+		t.Errorf("expected bob's backorder to be filled, got %v (%v)", filled, err)
+	}
+}
+
+func TestCreateOrderRejectsDuplicateProducts(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	maxQuantity := 2
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{
+		Name:               "Limited",
+		Stock:              10,
+		OrderQuantityRules: entity.OrderQuantityRules{MaxOrderQuantity: &maxQuantity},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	// Split across lines, the order would get around max_order_quantity
+	_, _, err = env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{
+		UserID: "user-1",
+		Items: []input.OrderItemRequest{
+			{ProductID: product.ID, Quantity: 2},
+			{ProductID: product.ID, Quantity: 2},
+		},
+	})
+	var domainErr *domainerr.Error
+	if !errors.As(err, &domainErr) || !errors.Is(err, domainerr.ErrValidation) || len(domainErr.Fields) != 1 || domainErr.Fields[0].Code != "duplicate" {
+		t.Fatalf("expected a duplicate validation error, got %v", err)
+	}
+
+	orders, err := env.Orders.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("failed to list orders: %v", err)
+	}
+	if len(orders) != 0 {
+		t.Errorf("expected no order to be created, got %d", len(orders))
+	}
+}
+
+func TestCreateOrderHonoursWarehouseUnderEveryStrategy(t *testing.T) {
+	for _, strategy := range []string{allocation.FirstFit, allocation.HighestStock, allocation.Preferred} {
+		t.Run(strategy, func(t *testing.T) {
+			env := testenv.New(t, testenv.Config{Strategy: strategy})
+			ctx := authz.AsSystem(context.Background())
+
+			product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Widget", Stock: 10})
+			if err != nil {
+				t.Fatalf("failed to create product: %v", err)
+			}
+			east, err := env.WarehouseUseCase.CreateWarehouse(ctx, input.CreateWarehouseRequest{Code: "EAST", Name: "East"})
+			if err != nil {
+				t.Fatalf("failed to create warehouse: %v", err)
+			}
+			if _, _, err := env.StockUseCase.Restock(ctx, product.ID, input.RestockRequest{Quantity: 2, Reason: "delivery", WarehouseID: east.ID}); err != nil {
+				t.Fatalf("failed to restock: %v", err)
+			}
+
+			// Every strategy on its own would pick the primary warehouse
+			order, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: "user-1", Quantity: 2, WarehouseID: east.ID})
+			if err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+			if got := order.Items[0].WarehouseID; got != east.ID {
+				t.Errorf("line allocated to warehouse %d, want the requested %d", got, east.ID)
+			}
+
+			// Once the requested warehouse is short, the strategy picks
+			order, _, err = env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: "user-2", Quantity: 1, WarehouseID: east.ID})
+			if err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+			if got := order.Items[0].WarehouseID; got == east.ID {
+				t.Errorf("line allocated to warehouse %d, which has no stock left", got)
+			}
+		})
+	}
+}
+
+func TestBackordersFillInQueueOrder(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	depth := 3
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Scarce", AllowBackorder: true, MaxBackorderDepth: &depth})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	backorder := func(user string, quantity int) *entity.Order {
+		t.Helper()
+		order, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: user, Quantity: quantity})
+		if err != nil || order.Status != entity.OrderStatusBackordered {
+			t.Fatalf("expected a backorder for %s, got %v (%v)", user, order, err)
+		}
+		return order
+	}
+	restock := func(quantity int) {
+		t.Helper()
+		if _, _, err := env.StockUseCase.Restock(ctx, product.ID, input.RestockRequest{Quantity: quantity, Reason: "delivery"}); err != nil {
+			t.Fatalf("failed to restock: %v", err)
+		}
+	}
+	statuses := func(orders ...*entity.Order) []string {
+		t.Helper()
+		got := make([]string, len(orders))
+		for i, order := range orders {
+			reloaded, err := env.OrderUseCase.GetOrder(ctx, order.ID)
+			if err != nil {
+				t.Fatalf("failed to reload order %d: %v", order.ID, err)
+			}
+			got[i] = reloaded.Status
+		}
+		return got
+	}
+
+	first, second, third := backorder("user-1", 2), backorder("user-2", 3), backorder("user-3", 1)
+
+	// The queue is capped at max_backorder_depth
+	if _, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: "user-4", Quantity: 1}); !errors.Is(err, domainerr.ErrBackorderQueueFull) {
+		t.Fatalf("backorder beyond the queue depth: expected ErrBackorderQueueFull, got %v", err)
+	}
+
+	// Enough for the two oldest fills exactly those two
+	restock(5)
+	want := []string{entity.OrderStatusPending, entity.OrderStatusPending, entity.OrderStatusBackordered}
+	if got := statuses(first, second, third); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("after restocking 5: statuses %v, want %v", got, want)
+	}
+
+	// A backorder that cannot be filled holds back the smaller ones behind it
+	fourth, fifth := backorder("user-4", 4), backorder("user-5", 1)
+	restock(2)
+	want = []string{entity.OrderStatusPending, entity.OrderStatusBackordered, entity.OrderStatusBackordered}
+	if got := statuses(third, fourth, fifth); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("after restocking 2: statuses %v, want %v", got, want)
+	}
+
+	restock(4)
+	want = []string{entity.OrderStatusPending, entity.OrderStatusPending}
+	if got := statuses(fourth, fifth); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("after restocking 4 more: statuses %v, want %v", got, want)
+	}
+}
+
+func TestOrderQuantityRules(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	minQuantity, maxQuantity, step := 2, 10, 2
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{
+		Name:  "Packs",
+		Stock: 100,
+		OrderQuantityRules: entity.OrderQuantityRules{
+			MinOrderQuantity:  &minQuantity,
+			MaxOrderQuantity:  &maxQuantity,
+			OrderQuantityStep: &step,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		quantity int
+		wantErr  error
+	}{
+		{"below the minimum", 1, domainerr.ErrOrderQuantityRule},
+		{"at the minimum", 2, nil},
+		{"off the step", 5, domainerr.ErrOrderQuantityRule},
+		{"on the step", 6, nil},
+		{"at the maximum", 10, nil},
+		{"above the maximum", 12, domainerr.ErrOrderQuantityRule},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: fmt.Sprintf("user-%d", i), Quantity: tc.quantity})
+			if tc.wantErr == nil && err != nil {
+				t.Errorf("ordering %d: %v", tc.quantity, err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("ordering %d: expected %v, got %v", tc.quantity, tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOrderPerUserLimit(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	const window = time.Hour
+	maxPerUser, windowSeconds := 6, int(window/time.Second)
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{
+		Name:  "Limited",
+		Stock: 100,
+		OrderQuantityRules: entity.OrderQuantityRules{
+			MaxPerUser:              &maxPerUser,
+			MaxPerUserWindowSeconds: &windowSeconds,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	// earlier is an order the user placed before, age ago
+	type earlier struct {
+		quantity  int
+		age       time.Duration
+		cancelled bool
+	}
+
+	tests := []struct {
+		name     string
+		earlier  []earlier
+		quantity int
+		wantErr  error
+	}{
+		{"up to the limit", nil, 6, nil},
+		{"over the limit", nil, 7, domainerr.ErrPurchaseLimit},
+		{"recent orders count", []earlier{{4, time.Minute, false}}, 3, domainerr.ErrPurchaseLimit},
+		{"recent orders leave the rest", []earlier{{4, time.Minute, false}}, 2, nil},
+		{"cancelled orders do not count", []earlier{{4, time.Minute, true}}, 6, nil},
+		{"orders just inside the window count", []earlier{{4, window - time.Minute, false}}, 3, domainerr.ErrPurchaseLimit},
+		{"orders outside the window do not count", []earlier{{4, window + time.Minute, false}}, 6, nil},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := fmt.Sprintf("user-%d", i)
+			for _, e := range tc.earlier {
+				order, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: user, Quantity: e.quantity})
+				if err != nil {
+					t.Fatalf("failed to place earlier order: %v", err)
+				}
+				if e.cancelled {
+					if _, err := env.OrderUseCase.CancelOrder(ctx, order.ID); err != nil {
+						t.Fatalf("failed to cancel earlier order: %v", err)
+					}
+				}
+				if _, err := env.DB.DB.ExecContext(ctx, `UPDATE orders SET created_at = ? WHERE id = ?`, time.Now().Add(-e.age), order.ID); err != nil {
+					t.Fatalf("failed to backdate earlier order: %v", err)
+				}
+			}
+
+			_, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: user, Quantity: tc.quantity})
+			if tc.wantErr == nil && err != nil {
+				t.Errorf("ordering %d: %v", tc.quantity, err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("ordering %d: expected %v, got %v", tc.quantity, tc.wantErr, err)
+			}
+		})
+	}
+
+	// The window starts at since inclusive: an order placed exactly then counts
+	order, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: "boundary", Quantity: 1})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	placed, err := env.Orders.GetByID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("failed to reload order: %v", err)
+	}
+	if sum, err := env.Orders.SumUserQuantity(ctx, "boundary", product.ID, placed.CreatedAt); err != nil || sum != 1 {
+		t.Errorf("sum since the order was placed = %d (%v), want 1", sum, err)
+	}
+	if sum, err := env.Orders.SumUserQuantity(ctx, "boundary", product.ID, placed.CreatedAt.Add(time.Microsecond)); err != nil || sum != 0 {
+		t.Errorf("sum since just after the order was placed = %d (%v), want 0", sum, err)
+	}
+}
+
+func TestReservationsCompleteOrExpire(t *testing.T) {
+	const ttl = 200 * time.Millisecond
+	env := testenv.New(t, testenv.Config{ReservationTTL: ttl})
+	ctx := authz.AsSystem(context.Background())
+
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Widget", Stock: 10})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	checkStock := func(when string, stock, reserved int) {
+		t.Helper()
+		got, err := env.Products.GetbyID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("failed to reload product: %v", err)
+		}
+		if got.Stock != stock || got.Reserved != reserved || got.Available != stock-reserved {
+			t.Errorf("%s: stock %d, reserved %d, available %d; want %d, %d, %d", when, got.Stock, got.Reserved, got.Available, stock, reserved, stock-reserved)
+		}
+	}
+
+	completed, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: "user-1", Quantity: 2})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	lapsed, _, err := env.OrderUseCase.CreateOrder(ctx, input.CreateOrderRequest{ProductID: product.ID, UserID: "user-2", Quantity: 3})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	checkStock("after placing both orders", 10, 5)
+
+	// Completing takes the held stock out of inventory
+	if _, err := env.OrderUseCase.CompleteOrder(ctx, completed.ID); err != nil {
+		t.Fatalf("failed to complete order: %v", err)
+	}
+	checkStock("after completing an order", 8, 3)
+
+	time.Sleep(ttl + 50*time.Millisecond)
+
+	if _, err := env.OrderUseCase.CompleteOrder(ctx, lapsed.ID); !errors.Is(err, domainerr.ErrReservationExpired) {
+		t.Errorf("completing a lapsed order: expected ErrReservationExpired, got %v", err)
+	}
+
+	// Expiry cancels the lapsed order and releases what it held
+	if expired, err := env.OrderUseCase.ExpireReservations(ctx); err != nil || expired != 1 {
+		t.Fatalf("ExpireReservations = %d, %v; want 1, nil", expired, err)
+	}
+	if order, err := env.OrderUseCase.GetOrder(ctx, lapsed.ID); err != nil || order.Status != entity.OrderStatusCancelled {
+		t.Errorf("expected the lapsed order to be cancelled, got %v (%v)", order, err)
+	}
+	if order, err := env.OrderUseCase.GetOrder(ctx, completed.ID); err != nil || order.Status != entity.OrderStatusCompleted {
+		t.Errorf("expected the completed order to stay completed, got %v (%v)", order, err)
+	}
+	checkStock("after expiry", 8, 0)
+
+	if expired, err := env.OrderUseCase.ExpireReservations(ctx); err != nil || expired != 0 {
+		t.Errorf("second ExpireReservations = %d, %v; want 0, nil", expired, err)
+	}
+
+	discrepancies, err := env.StockUseCase.VerifyStockLedger(ctx)
+	if err != nil {
+		t.Fatalf("failed to verify stock ledger: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("stock ledger does not match stock: %+v", discrepancies)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
 	"github.com/WaveCE29/product_order_system/internal/application/port/input"
 	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 	"github.com/WaveCE29/product_order_system/internal/domain/repository"
@@ -13,32 +14,93 @@ import (
 
 type productUseCase struct {
 	productRepo repository.ProductRepository
+	txManager   repository.TransactionManager
+	policy      authz.Policy
 	logger      logger.Logger
 }
 
 // GetAllProduct implements input.ProductUseCase.
-func (p *productUseCase) GetAllProduct(ctx context.Context) ([]*entity.Product, error) {
-	p.logger.Info("Getting all products")
+func (p *productUseCase) GetAllProduct(ctx context.Context, page input.PageRequest) ([]*entity.Product, string, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsRead); err != nil {
+		return nil, "", err
+	}
+
+	p.logger.Info("Getting all products", "limit", page.Limit, "sort", page.Sort)
 
-	products, err := p.productRepo.GetAll(ctx)
+	products, nextCursor, err := p.productRepo.List(ctx, toRepositoryPage(page))
 	if err != nil {
 		p.logger.Error("Failed to get products", "error", err)
-		return nil, fmt.Errorf("failed to get products: %w", err)
+		return nil, "", fmt.Errorf("failed to get products: %w", err)
 	}
 
 	p.logger.Info("Retrieved products", "count", len(products))
-	return products, nil
+	return products, nextCursor, nil
+}
+
+// ListLowStockProducts implements input.ProductUseCase.
+func (p *productUseCase) ListLowStockProducts(ctx context.Context, page input.PageRequest) ([]*entity.Product, string, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsRead); err != nil {
+		return nil, "", err
+	}
+
+	p.logger.Info("Listing low-stock products", "limit", page.Limit, "sort", page.Sort)
+
+	products, nextCursor, err := p.productRepo.ListLowStock(ctx, toRepositoryPage(page))
+	if err != nil {
+		p.logger.Error("Failed to list low-stock products", "error", err)
+		return nil, "", fmt.Errorf("failed to list low-stock products: %w", err)
+	}
+
+	p.logger.Info("Retrieved low-stock products", "count", len(products))
+	return products, nextCursor, nil
+}
+
+// SearchProducts implements input.ProductUseCase.
+func (p *productUseCase) SearchProducts(ctx context.Context, req input.SearchProductsRequest) ([]*entity.ProductSearchResult, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsRead); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Searching products", "query", req.Query, "limit", req.Limit, "offset", req.Offset)
+
+	limit := repository.PageRequest{Limit: req.Limit}.PageLimit()
+	results, err := p.productRepo.Search(ctx, req.Query, limit, req.Offset)
+	if err != nil {
+		p.logger.Error("Failed to search products", "query", req.Query, "error", err)
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+
+	p.logger.Info("Product search completed", "query", req.Query, "count", len(results))
+	return results, nil
 }
 
 // CreateProduct implements input.ProductUseCase.
 func (p *productUseCase) CreateProduct(ctx context.Context, req input.CreateProductRequest) (*entity.Product, error) {
-	p.logger.Info("Creating new product", "name", req.Name, "stock", req.Stock)
+	if err := p.policy.Require(ctx, entity.PermProductsWrite); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Creating new product", "sku", req.SKU, "name", req.Name, "stock", req.Stock, "price", req.Price, "currency", req.Currency)
 
 	product := &entity.Product{
-		Name:      req.Name,
-		Stock:     req.Stock,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		SKU:                req.SKU,
+		Name:               req.Name,
+		Description:        req.Description,
+		Category:           req.Category,
+		Barcode:            req.Barcode,
+		Stock:              req.Stock,
+		Price:              req.Price,
+		Currency:           currencyOrDefault(req.Currency),
+		ReorderThreshold:   req.ReorderThreshold,
+		AllowBackorder:     req.AllowBackorder,
+		MaxBackorderDepth:  req.MaxBackorderDepth,
+		OrderQuantityRules: req.OrderQuantityRules,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if err := product.OrderQuantityRules.Validate(); err != nil {
+		return nil, err
 	}
 
 	if err := p.productRepo.Create(ctx, product); err != nil {
@@ -53,6 +115,10 @@ func (p *productUseCase) CreateProduct(ctx context.Context, req input.CreateProd
 
 // GetProduct implements input.ProductUseCase.
 func (p *productUseCase) GetProduct(ctx context.Context, id int) (*entity.Product, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsRead); err != nil {
+		return nil, err
+	}
+
 	p.logger.Info("Getting product", "id", id)
 
 	product, err := p.productRepo.GetbyID(ctx, id)
@@ -64,10 +130,170 @@ func (p *productUseCase) GetProduct(ctx context.Context, id int) (*entity.Produc
 	return product, nil
 }
 
-func NewProductUseCase(productRepo repository.ProductRepository, logger logger.Logger) input.ProductUseCase {
+// GetProductBySKU implements input.ProductUseCase.
+func (p *productUseCase) GetProductBySKU(ctx context.Context, sku string) (*entity.Product, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsRead); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Getting product by SKU", "sku", sku)
+
+	product, err := p.productRepo.GetBySKU(ctx, sku)
+	if err != nil {
+		p.logger.Error("Failed to get product by SKU", "sku", sku, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return product, nil
+}
+
+// UpdateProduct implements input.ProductUseCase.
+func (p *productUseCase) UpdateProduct(ctx context.Context, id int, req input.UpdateProductRequest) (*entity.Product, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsWrite); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Updating product", "id", id, "name", req.Name)
+
+	return p.modifyProduct(ctx, id, func(product *entity.Product) {
+		product.SKU = req.SKU
+		product.Name = req.Name
+		product.Description = req.Description
+		product.Category = req.Category
+		product.Barcode = req.Barcode
+		product.Price = req.Price
+		product.Currency = currencyOrDefault(req.Currency)
+		product.ReorderThreshold = req.ReorderThreshold
+		product.AllowBackorder = req.AllowBackorder
+		product.MaxBackorderDepth = req.MaxBackorderDepth
+		product.OrderQuantityRules = req.OrderQuantityRules
+	})
+}
+
+// PatchProduct implements input.ProductUseCase.
+func (p *productUseCase) PatchProduct(ctx context.Context, id int, req input.PatchProductRequest) (*entity.Product, error) {
+	if err := p.policy.Require(ctx, entity.PermProductsWrite); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Patching product", "id", id)
+
+	return p.modifyProduct(ctx, id, func(product *entity.Product) {
+		if req.SKU != nil {
+			product.SKU = *req.SKU
+		}
+		if req.Name != nil {
+			product.Name = *req.Name
+		}
+		if req.Description != nil {
+			product.Description = *req.Description
+		}
+		if req.Category != nil {
+			product.Category = *req.Category
+		}
+		if req.Barcode != nil {
+			product.Barcode = *req.Barcode
+		}
+		if req.Price != nil {
+			product.Price = *req.Price
+		}
+		if req.Currency != nil {
+			product.Currency = *req.Currency
+		}
+		patchInt(&product.ReorderThreshold, req.ReorderThreshold, req.ClearReorderThreshold)
+		if req.AllowBackorder != nil {
+			product.AllowBackorder = *req.AllowBackorder
+		}
+		patchInt(&product.MaxBackorderDepth, req.MaxBackorderDepth, req.ClearMaxBackorderDepth)
+		patchInt(&product.MinOrderQuantity, req.MinOrderQuantity, req.ClearMinOrderQuantity)
+		patchInt(&product.MaxOrderQuantity, req.MaxOrderQuantity, req.ClearMaxOrderQuantity)
+		patchInt(&product.OrderQuantityStep, req.OrderQuantityStep, req.ClearOrderQuantityStep)
+		patchInt(&product.MaxPerUser, req.MaxPerUser, req.ClearMaxPerUser)
+		patchInt(&product.MaxPerUserWindowSeconds, req.MaxPerUserWindowSeconds, req.ClearMaxPerUserWindowSeconds)
+	})
+}
+
+// patchInt applies an optional numeric patch field to target.
+func patchInt(target **int, value *int, clear bool) {
+	if clear {
+		*target = nil
+	} else if value != nil {
+		*target = value
+	}
+}
+
+// DeleteProduct implements input.ProductUseCase.
+func (p *productUseCase) DeleteProduct(ctx context.Context, id int) error {
+	if err := p.policy.Require(ctx, entity.PermProductsWrite); err != nil {
+		return err
+	}
+
+	p.logger.Info("Deleting product", "id", id)
+
+	if err := p.productRepo.Delete(ctx, id); err != nil {
+		p.logger.Error("Failed to delete product", "id", id, "error", err)
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	p.logger.Info("Product deleted successfully", "id", id)
+	return nil
+}
+
+// modifyProduct loads a product, applies apply and saves it in one transaction,
+// rejecting order quantity rules that are inconsistent once applied. Stock is
+// never written here; it changes only through the stock use case, which
+// records every change in the ledger.
+func (p *productUseCase) modifyProduct(ctx context.Context, id int, apply func(product *entity.Product)) (*entity.Product, error) {
+	var product *entity.Product
+	err := p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := p.productRepo.GetbyID(ctx, id)
+		if err != nil {
+			p.logger.Error("Failed to get product", "id", id, "error", err)
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+
+		apply(existing)
+		if err := existing.OrderQuantityRules.Validate(); err != nil {
+			return err
+		}
+
+		if err := p.productRepo.Update(ctx, existing); err != nil {
+			p.logger.Error("Failed to update product", "id", id, "error", err)
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+
+		product = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Product updated successfully", "id", id)
+	return product, nil
+}
+
+func NewProductUseCase(productRepo repository.ProductRepository, txManager repository.TransactionManager, policy authz.Policy, logger logger.Logger) input.ProductUseCase {
 	return &productUseCase{
 		productRepo: productRepo,
+		txManager:   txManager,
+		policy:      policy,
 		logger:      logger,
 	}
 
 }
+
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return entity.DefaultCurrency
+	}
+	return currency
+}
+
+func toRepositoryPage(page input.PageRequest) repository.PageRequest {
+	return repository.PageRequest{
+		Limit:  page.Limit,
+		Cursor: page.Cursor,
+		Sort:   page.Sort,
+	}
+}
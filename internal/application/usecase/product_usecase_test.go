@@ -0,0 +1,46 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/internal/testenv"
+)
+
+func TestProductUpdatesLeaveStockUnchanged(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Widget", Stock: 7})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	if _, err := env.ProductUseCase.UpdateProduct(ctx, product.ID, input.UpdateProductRequest{Name: "Renamed"}); err != nil {
+		t.Fatalf("failed to update product: %v", err)
+	}
+	name := "Patched"
+	if _, err := env.ProductUseCase.PatchProduct(ctx, product.ID, input.PatchProductRequest{Name: &name}); err != nil {
+		t.Fatalf("failed to patch product: %v", err)
+	}
+
+	got, err := env.Products.GetbyID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	if got.Name != name || got.Stock != 7 {
+		t.Errorf("expected %q with stock 7, got %q with stock %d", name, got.Name, got.Stock)
+	}
+
+	// Only the opening balance is in the ledger
+	movements, _, err := env.Movements.ListByProduct(ctx, product.ID, repository.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list stock movements: %v", err)
+	}
+	if len(movements) != 1 || movements[0].Delta != 7 {
+		t.Errorf("expected only the opening balance of 7 in the ledger, got %d movements", len(movements))
+	}
+}
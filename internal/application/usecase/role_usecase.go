@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+type roleUseCase struct {
+	roleRepo repository.RoleRepository
+	policy   authz.Policy
+	logger   logger.Logger
+}
+
+// ListRoles implements input.RoleUseCase.
+func (r *roleUseCase) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	if err := r.policy.Require(ctx, entity.PermRolesManage); err != nil {
+		return nil, err
+	}
+
+	roles, err := r.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ListRoleAssignments implements input.RoleUseCase.
+func (r *roleUseCase) ListRoleAssignments(ctx context.Context) ([]*entity.RoleAssignment, error) {
+	if err := r.policy.Require(ctx, entity.PermRolesManage); err != nil {
+		return nil, err
+	}
+
+	assignments, err := r.roleRepo.GetAssignments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// AssignRole implements input.RoleUseCase.
+func (r *roleUseCase) AssignRole(ctx context.Context, subject, role string) error {
+	if err := r.policy.Require(ctx, entity.PermRolesManage); err != nil {
+		return err
+	}
+	if strings.TrimSpace(subject) == "" {
+		return domainerr.Invalid("subject", "required", "subject is required")
+	}
+
+	err := r.roleRepo.Assign(ctx, &entity.RoleAssignment{
+		Subject:   subject,
+		Role:      role,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		r.logger.Error("Failed to assign role", "subject", subject, "role", role, "error", err)
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	r.logger.Info("Role assigned", "subject", subject, "role", role, "by", authz.Actor(ctx))
+	return nil
+}
+
+// UnassignRole implements input.RoleUseCase.
+func (r *roleUseCase) UnassignRole(ctx context.Context, subject, role string) error {
+	if err := r.policy.Require(ctx, entity.PermRolesManage); err != nil {
+		return err
+	}
+
+	if err := r.roleRepo.Unassign(ctx, subject, role); err != nil {
+		r.logger.Error("Failed to unassign role", "subject", subject, "role", role, "error", err)
+		return fmt.Errorf("failed to unassign role: %w", err)
+	}
+
+	r.logger.Info("Role unassigned", "subject", subject, "role", role, "by", authz.Actor(ctx))
+	return nil
+}
+
+func NewRoleUseCase(roleRepo repository.RoleRepository, policy authz.Policy, logger logger.Logger) input.RoleUseCase {
+	return &roleUseCase{
+		roleRepo: roleRepo,
+		policy:   policy,
+		logger:   logger,
+	}
+}
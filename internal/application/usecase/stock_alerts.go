@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+// lowStockAlerts collects the alerts raised inside a transaction so they are
+// only sent once it has committed.
+type lowStockAlerts []*entity.LowStockAlert
+
+// check records an alert if product's available stock, previously
+// previousAvailable, has just crossed its reorder threshold.
+func (a *lowStockAlerts) check(product *entity.Product, previousAvailable int) {
+	if product.CrossedReorderThreshold(previousAvailable) {
+		*a = append(*a, entity.NewLowStockAlert(product, previousAvailable))
+	}
+}
+
+// send hands the collected alerts to notifier. Failures are logged and
+// otherwise ignored: the stock change has already been made. The server
+// wraps its notifier in notification.AsyncNotifier, so a slow webhook does
+// not hold up the caller.
+func (a lowStockAlerts) send(ctx context.Context, notifier output.StockAlertNotifier, logger logger.Logger) {
+	for _, alert := range a {
+		logger.Info("Product crossed its reorder threshold",
+			"product_id", alert.ProductID,
+			"available", alert.Available,
+			"reorder_threshold", alert.ReorderThreshold)
+
+		if err := notifier.NotifyLowStock(ctx, alert); err != nil {
+			logger.Error("Failed to send low-stock alert", "product_id", alert.ProductID, "error", err)
+		}
+	}
+}
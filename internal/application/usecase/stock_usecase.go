@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+type stockUseCase struct {
+	productRepo   repository.ProductRepository
+	warehouseRepo repository.WarehouseRepository
+	movementRepo  repository.StockMovementRepository
+	txManager     repository.TransactionManager
+	backorders    input.BackorderFiller
+	notifier      output.StockAlertNotifier
+	policy        authz.Policy
+	logger        logger.Logger
+}
+
+// Restock implements input.StockUseCase.
+func (s *stockUseCase) Restock(ctx context.Context, productID int, req input.RestockRequest) (*entity.Product, *entity.StockMovement, error) {
+	if err := s.policy.Require(ctx, entity.PermStockWrite); err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Restocking product", "product_id", productID, "warehouse_id", req.WarehouseID, "quantity", req.Quantity, "reference_id", req.ReferenceID)
+
+	return s.changeStock(ctx, productID, req.WarehouseID, req.Quantity, repository.StockChange{
+		Reason:      entity.StockReasonRestock,
+		ReferenceID: req.ReferenceID,
+		Note:        req.Reason,
+		Actor:       authz.Actor(ctx),
+	})
+}
+
+// AdjustStock implements input.StockUseCase.
+func (s *stockUseCase) AdjustStock(ctx context.Context, productID int, req input.StockAdjustmentRequest) (*entity.Product, *entity.StockMovement, error) {
+	if err := s.policy.Require(ctx, entity.PermStockWrite); err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Adjusting product stock", "product_id", productID, "warehouse_id", req.WarehouseID, "delta", req.Delta, "reason", req.Reason)
+
+	return s.changeStock(ctx, productID, req.WarehouseID, req.Delta, repository.StockChange{
+		Reason:      entity.StockReasonAdjustment,
+		ReferenceID: req.ReferenceID,
+		Note:        req.Reason,
+		Actor:       authz.Actor(ctx),
+	})
+}
+
+// changeStock applies delta to a warehouse, the primary one if warehouseID is
+// 0, and reloads the product in one transaction so the returned stock is the
+// balance just recorded. A change that takes the product to its reorder
+// threshold raises a low-stock alert once the transaction commits, and stock
+// it adds is then offered to the product's backorders.
+func (s *stockUseCase) changeStock(ctx context.Context, productID int, warehouseID int, delta int, change repository.StockChange) (*entity.Product, *entity.StockMovement, error) {
+	var (
+		product  *entity.Product
+		movement *entity.StockMovement
+		alerts   lowStockAlerts
+	)
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		warehouse, err := s.getWarehouse(ctx, warehouseID)
+		if err != nil {
+			return err
+		}
+
+		movement, err = s.productRepo.AdjustStock(ctx, productID, warehouse.ID, delta, change)
+		if err != nil {
+			s.logger.Error("Failed to change product stock", "product_id", productID, "delta", delta, "error", err)
+			return fmt.Errorf("failed to change product stock: %w", err)
+		}
+
+		product, err = s.productRepo.GetbyID(ctx, productID)
+		if err != nil {
+			s.logger.Error("Failed to get product", "product_id", productID, "error", err)
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+
+		// Reservations are untouched, so available stock moved by delta as well
+		alerts.check(product, product.Available-delta)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	alerts.send(ctx, s.notifier, s.logger)
+	s.logger.Info("Product stock changed", "product_id", productID, "delta", delta, "stock", product.Stock)
+
+	if delta > 0 && fillBackorders(ctx, s.backorders, s.logger, productID) > 0 {
+		// Report the stock the filled backorders now hold
+		if filled, err := s.productRepo.GetbyID(ctx, productID); err == nil {
+			product = filled
+		} else {
+			s.logger.Error("Failed to get product", "product_id", productID, "error", err)
+		}
+	}
+	return product, movement, nil
+}
+
+// ListStockMovements implements input.StockUseCase.
+func (s *stockUseCase) ListStockMovements(ctx context.Context, productID int, page input.PageRequest) ([]*entity.StockMovement, string, error) {
+	if err := s.policy.Require(ctx, entity.PermStockRead); err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("Listing stock movements", "product_id", productID, "limit", page.Limit)
+
+	if _, err := s.productRepo.GetbyID(ctx, productID); err != nil {
+		s.logger.Error("Failed to get product", "product_id", productID, "error", err)
+		return nil, "", fmt.Errorf("failed to get product: %w", err)
+	}
+
+	movements, nextCursor, err := s.movementRepo.ListByProduct(ctx, productID, toRepositoryPage(page))
+	if err != nil {
+		s.logger.Error("Failed to list stock movements", "product_id", productID, "error", err)
+		return nil, "", fmt.Errorf("failed to list stock movements: %w", err)
+	}
+
+	s.logger.Info("Retrieved stock movements", "product_id", productID, "count", len(movements))
+	return movements, nextCursor, nil
+}
+
+// ListStockLevels implements input.StockUseCase.
+func (s *stockUseCase) ListStockLevels(ctx context.Context, productID int) ([]*entity.WarehouseStock, error) {
+	if err := s.policy.Require(ctx, entity.PermStockRead); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Listing stock levels", "product_id", productID)
+
+	if _, err := s.productRepo.GetbyID(ctx, productID); err != nil {
+		s.logger.Error("Failed to get product", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	levels, err := s.warehouseRepo.ListStock(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to list stock levels", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to list stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// TransferStock implements input.StockUseCase.
+func (s *stockUseCase) TransferStock(ctx context.Context, productID int, req input.TransferStockRequest) (*entity.StockTransfer, []*entity.WarehouseStock, error) {
+	if err := s.policy.Require(ctx, entity.PermStockWrite); err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Transferring product stock",
+		"product_id", productID,
+		"from_warehouse_id", req.FromWarehouseID,
+		"to_warehouse_id", req.ToWarehouseID,
+		"quantity", req.Quantity)
+
+	transfer := &entity.StockTransfer{
+		ProductID:       productID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		Note:            req.Reason,
+		Actor:           authz.Actor(ctx),
+	}
+
+	var levels []*entity.WarehouseStock
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.productRepo.GetbyID(ctx, productID); err != nil {
+			s.logger.Error("Failed to get product", "product_id", productID, "error", err)
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+
+		for _, id := range []int{req.FromWarehouseID, req.ToWarehouseID} {
+			if _, err := s.getWarehouse(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := s.productRepo.TransferStock(ctx, transfer); err != nil {
+			s.logger.Error("Failed to transfer product stock", "product_id", productID, "error", err)
+			return fmt.Errorf("failed to transfer product stock: %w", err)
+		}
+
+		var err error
+		levels, err = s.warehouseRepo.ListStock(ctx, productID)
+		if err != nil {
+			s.logger.Error("Failed to list stock levels", "product_id", productID, "error", err)
+			return fmt.Errorf("failed to list stock levels: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Product stock transferred", "product_id", productID, "transfer_id", transfer.ID)
+	return transfer, levels, nil
+}
+
+// getWarehouse loads a warehouse by ID, or the primary warehouse if id is 0.
+func (s *stockUseCase) getWarehouse(ctx context.Context, id int) (*entity.Warehouse, error) {
+	var (
+		warehouse *entity.Warehouse
+		err       error
+	)
+	if id == 0 {
+		warehouse, err = s.warehouseRepo.GetPrimary(ctx)
+	} else {
+		warehouse, err = s.warehouseRepo.GetByID(ctx, id)
+	}
+	if err != nil {
+		s.logger.Error("Failed to get warehouse", "warehouse_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+	return warehouse, nil
+}
+
+// VerifyStockLedger implements input.StockUseCase.
+func (s *stockUseCase) VerifyStockLedger(ctx context.Context) ([]entity.StockDiscrepancy, error) {
+	if err := s.policy.Require(ctx, entity.PermStockRead); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Verifying stock ledger")
+
+	discrepancies, err := s.movementRepo.Verify(ctx)
+	if err != nil {
+		s.logger.Error("Failed to verify stock ledger", "error", err)
+		return nil, fmt.Errorf("failed to verify stock ledger: %w", err)
+	}
+
+	for _, d := range discrepancies {
+		s.logger.Warn("Stock does not match ledger",
+			"product_id", d.ProductID,
+			"warehouse_id", d.WarehouseID,
+			"stock", d.Stock,
+			"ledger_sum", d.LedgerSum)
+	}
+
+	s.logger.Info("Stock ledger verified", "discrepancies", len(discrepancies))
+	return discrepancies, nil
+}
+
+func NewStockUseCase(productRepo repository.ProductRepository, warehouseRepo repository.WarehouseRepository, movementRepo repository.StockMovementRepository, txManager repository.TransactionManager, backorders input.BackorderFiller, notifier output.StockAlertNotifier, policy authz.Policy, logger logger.Logger) input.StockUseCase {
+	return &stockUseCase{
+		productRepo:   productRepo,
+		warehouseRepo: warehouseRepo,
+		movementRepo:  movementRepo,
+		txManager:     txManager,
+		backorders:    backorders,
+		notifier:      notifier,
+		policy:        policy,
+		logger:        logger,
+	}
+}
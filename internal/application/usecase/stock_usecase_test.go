@@ -0,0 +1,141 @@
+package usecase_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/internal/testenv"
+)
+
+// slowNotifier holds each alert until release is closed, like a webhook that
+// is slow to answer.
+type slowNotifier struct {
+	release chan struct{}
+	sent    chan error
+}
+
+func (n *slowNotifier) NotifyLowStock(ctx context.Context, alert *entity.LowStockAlert) error {
+	<-n.release
+	n.sent <- ctx.Err()
+	return nil
+}
+
+func TestSlowAlertDoesNotBlockStockChange(t *testing.T) {
+	notifier := &slowNotifier{release: make(chan struct{}), sent: make(chan error, 1)}
+	env := testenv.New(t, testenv.Config{Notifier: notifier})
+	ctx, cancel := context.WithCancel(authz.AsSystem(context.Background()))
+
+	threshold := 5
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Widget", Stock: 10, ReorderThreshold: &threshold})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := env.StockUseCase.AdjustStock(ctx, product.ID, input.StockAdjustmentRequest{Delta: -6, Reason: "count"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to adjust stock: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stock adjustment waited for the alert to be delivered")
+	}
+
+	// The caller going away does not cancel the delivery
+	cancel()
+	close(notifier.release)
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelDrain()
+	if err := env.Alerts.Drain(drainCtx); err != nil {
+		t.Fatalf("alert was never delivered: %v", err)
+	}
+	if err := <-notifier.sent; err != nil {
+		t.Errorf("alert delivered with a cancelled context: %v", err)
+	}
+}
+
+func TestTransferStockMovesStockAndRecordsLedger(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	product, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: "Widget", Stock: 10})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	east, err := env.WarehouseUseCase.CreateWarehouse(ctx, input.CreateWarehouseRequest{Code: "EAST", Name: "East"})
+	if err != nil {
+		t.Fatalf("failed to create warehouse: %v", err)
+	}
+	levels, err := env.Warehouses.ListStock(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("failed to list stock levels: %v", err)
+	}
+	primary := 0
+	for _, level := range levels {
+		if level.Stock == 10 {
+			primary = level.WarehouseID
+		}
+	}
+	if primary == 0 || primary == east.ID {
+		t.Fatalf("opening stock is not in the primary warehouse")
+	}
+
+	transfer, levels, err := env.StockUseCase.TransferStock(ctx, product.ID, input.TransferStockRequest{
+		FromWarehouseID: primary, ToWarehouseID: east.ID, Quantity: 4, Reason: "rebalance",
+	})
+	if err != nil {
+		t.Fatalf("failed to transfer stock: %v", err)
+	}
+
+	stock := make(map[int]int)
+	for _, level := range levels {
+		stock[level.WarehouseID] = level.Stock
+	}
+	if stock[primary] != 6 || stock[east.ID] != 4 {
+		t.Errorf("stock after transfer = %v, want 6 in %d and 4 in %d", stock, primary, east.ID)
+	}
+	if got, err := env.Products.GetbyID(ctx, product.ID); err != nil || got.Stock != 10 {
+		t.Errorf("product total after transfer = %v (%v), want 10", got, err)
+	}
+
+	movements, _, err := env.Movements.ListByProduct(ctx, product.ID, repository.PageRequest{})
+	if err != nil {
+		t.Fatalf("failed to list movements: %v", err)
+	}
+	deltas := make(map[int]int)
+	for _, m := range movements {
+		if m.Reason != entity.StockReasonTransfer {
+			continue
+		}
+		if m.ReferenceID != strconv.Itoa(transfer.ID) {
+			t.Errorf("transfer movement references %q, want transfer %d", m.ReferenceID, transfer.ID)
+		}
+		if m.Balance != stock[m.WarehouseID] {
+			t.Errorf("transfer movement in warehouse %d has balance %d, want %d", m.WarehouseID, m.Balance, stock[m.WarehouseID])
+		}
+		deltas[m.WarehouseID] += m.Delta
+	}
+	if len(deltas) != 2 || deltas[primary] != -4 || deltas[east.ID] != 4 {
+		t.Errorf("transfer movements = %v, want -4 in %d and +4 in %d", deltas, primary, east.ID)
+	}
+
+	discrepancies, err := env.StockUseCase.VerifyStockLedger(ctx)
+	if err != nil {
+		t.Fatalf("failed to verify stock ledger: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("stock ledger does not match stock: %+v", discrepancies)
+	}
+}
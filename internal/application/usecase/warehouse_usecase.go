@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+type warehouseUseCase struct {
+	warehouseRepo repository.WarehouseRepository
+	policy        authz.Policy
+	logger        logger.Logger
+}
+
+// CreateWarehouse implements input.WarehouseUseCase.
+func (w *warehouseUseCase) CreateWarehouse(ctx context.Context, req input.CreateWarehouseRequest) (*entity.Warehouse, error) {
+	if err := w.policy.Require(ctx, entity.PermWarehousesWrite); err != nil {
+		return nil, err
+	}
+
+	w.logger.Info("Creating new warehouse", "code", req.Code, "name", req.Name)
+
+	now := time.Now()
+	warehouse := &entity.Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := w.warehouseRepo.Create(ctx, warehouse); err != nil {
+		w.logger.Error("Failed to create warehouse", "error", err)
+		return nil, fmt.Errorf("failed to create warehouse: %w", err)
+	}
+
+	w.logger.Info("Warehouse created successfully", "id", warehouse.ID)
+	return warehouse, nil
+}
+
+// GetWarehouse implements input.WarehouseUseCase.
+func (w *warehouseUseCase) GetWarehouse(ctx context.Context, id int) (*entity.Warehouse, error) {
+	if err := w.policy.Require(ctx, entity.PermWarehousesRead); err != nil {
+		return nil, err
+	}
+
+	w.logger.Info("Getting warehouse", "id", id)
+
+	warehouse, err := w.warehouseRepo.GetByID(ctx, id)
+	if err != nil {
+		w.logger.Error("Failed to get warehouse", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+// GetAllWarehouses implements input.WarehouseUseCase.
+func (w *warehouseUseCase) GetAllWarehouses(ctx context.Context) ([]*entity.Warehouse, error) {
+	if err := w.policy.Require(ctx, entity.PermWarehousesRead); err != nil {
+		return nil, err
+	}
+
+	w.logger.Info("Getting all warehouses")
+
+	warehouses, err := w.warehouseRepo.GetAll(ctx)
+	if err != nil {
+		w.logger.Error("Failed to get warehouses", "error", err)
+		return nil, fmt.Errorf("failed to get warehouses: %w", err)
+	}
+
+	w.logger.Info("Retrieved warehouses", "count", len(warehouses))
+	return warehouses, nil
+}
+
+func NewWarehouseUseCase(warehouseRepo repository.WarehouseRepository, policy authz.Policy, logger logger.Logger) input.WarehouseUseCase {
+	return &warehouseUseCase{
+		warehouseRepo: warehouseRepo,
+		policy:        policy,
+		logger:        logger,
+	}
+}
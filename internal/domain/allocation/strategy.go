@@ -0,0 +1,95 @@
+// Package allocation decides which warehouse fulfils an order line.
+package allocation
+
+import (
+	"fmt"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+// Names of the built-in strategies, as accepted by NewStrategy. Preferred is
+// kept for configurations written when only it honoured a line's preferred
+// warehouse; it now behaves as FirstFit.
+const (
+	FirstFit     = "first_fit"
+	HighestStock = "highest_stock"
+	Preferred    = "preferred"
+)
+
+// Line is an order line to allocate. PreferredWarehouseID is the warehouse
+// the order asked to be shipped from, or 0 for none.
+type Line struct {
+	ProductID            int
+	Quantity             int
+	PreferredWarehouseID int
+}
+
+// Strategy picks the warehouse an order line is fulfilled from. levels are
+// the product's stock levels ordered by warehouse ID. A line is never split,
+// so ok is false when no single warehouse has enough available stock.
+type Strategy interface {
+	Allocate(line Line, levels []*entity.WarehouseStock) (warehouseID int, ok bool)
+}
+
+// NewStrategy returns the built-in strategy called name. Every built-in
+// strategy allocates a line from its preferred warehouse when that warehouse
+// can fulfil it, and otherwise picks one its own way.
+func NewStrategy(name string) (Strategy, error) {
+	switch name {
+	case FirstFit, Preferred:
+		return NewPreferred(firstFit{}), nil
+	case HighestStock:
+		return NewPreferred(highestStock{}), nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q", name)
+	}
+}
+
+// firstFit allocates from the first warehouse, by ID, that can fulfil the
+// line.
+type firstFit struct{}
+
+func (firstFit) Allocate(line Line, levels []*entity.WarehouseStock) (int, bool) {
+	for _, level := range levels {
+		if level.Available >= line.Quantity {
+			return level.WarehouseID, true
+		}
+	}
+	return 0, false
+}
+
+// highestStock allocates from the warehouse with the most available stock,
+// keeping stock spread evenly. Ties go to the lowest warehouse ID.
+type highestStock struct{}
+
+func (highestStock) Allocate(line Line, levels []*entity.WarehouseStock) (int, bool) {
+	var best *entity.WarehouseStock
+	for _, level := range levels {
+		if best == nil || level.Available > best.Available {
+			best = level
+		}
+	}
+	if best == nil || best.Available < line.Quantity {
+		return 0, false
+	}
+	return best.WarehouseID, true
+}
+
+type preferred struct {
+	fallback Strategy
+}
+
+// NewPreferred returns a strategy that allocates from the line's preferred
+// warehouse when it can fulfil the line, and otherwise defers to fallback.
+func NewPreferred(fallback Strategy) Strategy {
+	return preferred{fallback: fallback}
+}
+
+func (p preferred) Allocate(line Line, levels []*entity.WarehouseStock) (int, bool) {
+	for _, level := range levels {
+		if level.WarehouseID == line.PreferredWarehouseID && level.Available >= line.Quantity {
+			return level.WarehouseID, true
+		}
+	}
+	return p.fallback.Allocate(line, levels)
+}
@@ -0,0 +1,72 @@
+package allocation
+
+import (
+	"testing"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+func TestStrategies(t *testing.T) {
+	// Warehouses 1-3 with 5, 20 and 20 units available
+	levels := []*entity.WarehouseStock{
+		{WarehouseID: 1, Available: 5},
+		{WarehouseID: 2, Available: 20},
+		{WarehouseID: 3, Available: 20},
+	}
+
+	tests := []struct {
+		name      string
+		strategy  string
+		quantity  int
+		preferred int
+		want      int
+		wantOK    bool
+	}{
+		{"first fit takes the lowest ID that fits", FirstFit, 5, 0, 1, true},
+		{"first fit skips warehouses that are short", FirstFit, 6, 0, 2, true},
+		{"highest stock takes the most available", HighestStock, 1, 0, 2, true},
+		{"highest stock breaks ties by lowest ID", HighestStock, 20, 0, 2, true},
+		{"preferred with no preference is first fit", Preferred, 5, 0, 1, true},
+
+		{"first fit honours a preference that fits", FirstFit, 5, 3, 3, true},
+		{"highest stock honours a preference that fits", HighestStock, 5, 1, 1, true},
+		{"preferred honours a preference that fits", Preferred, 5, 3, 3, true},
+		{"first fit ignores a preference that is short", FirstFit, 6, 1, 2, true},
+		{"highest stock ignores a preference that is short", HighestStock, 6, 1, 2, true},
+		{"unknown preferred warehouse falls back", FirstFit, 1, 9, 1, true},
+
+		{"first fit never splits a line", FirstFit, 21, 0, 0, false},
+		{"highest stock never splits a line", HighestStock, 21, 2, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy, err := NewStrategy(tc.strategy)
+			if err != nil {
+				t.Fatalf("NewStrategy(%q) failed: %v", tc.strategy, err)
+			}
+			got, ok := strategy.Allocate(Line{ProductID: 1, Quantity: tc.quantity, PreferredWarehouseID: tc.preferred}, levels)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("Allocate = %d, %v; want %d, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestStrategyWithoutStockLevels(t *testing.T) {
+	for _, name := range []string{FirstFit, HighestStock, Preferred} {
+		strategy, err := NewStrategy(name)
+		if err != nil {
+			t.Fatalf("NewStrategy(%q) failed: %v", name, err)
+		}
+		if got, ok := strategy.Allocate(Line{ProductID: 1, Quantity: 1, PreferredWarehouseID: 1}, nil); ok {
+			t.Errorf("%s allocated warehouse %d with no stock levels", name, got)
+		}
+	}
+}
+
+func TestNewStrategyRejectsUnknownName(t *testing.T) {
+	if _, err := NewStrategy("round_robin"); err == nil {
+		t.Error("NewStrategy accepted an unknown strategy")
+	}
+}
@@ -0,0 +1,128 @@
+package domainerr
+
+import (
+	"fmt"
+)
+
+// Kind classifies a domain error so adapters can translate it (for example to
+// an HTTP status) without inspecting messages.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindNotFound
+	KindConflict
+	KindUnprocessable
+	KindUnavailable
+	KindUnauthenticated
+	KindForbidden
+)
+
+// Error is a classified domain error. Package-level sentinels identify each
+// failure; Withf derives an error carrying a specific message that still
+// matches its sentinel under errors.Is.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+
+	sentinel *Error
+}
+
+// FieldError describes a single invalid input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	ErrValidation        = newError(KindInvalid, "validation_failed", "request validation failed")
+	ErrInvalidCursor     = newError(KindInvalid, "invalid_cursor", "invalid cursor")
+	ErrInvalidSort       = newError(KindInvalid, "invalid_sort", "invalid sort field")
+	ErrInsufficientStock = newError(KindInvalid, "insufficient_stock", "insufficient stock")
+
+	ErrProductNotFound   = newError(KindNotFound, "product_not_found", "product not found")
+	ErrOrderNotFound     = newError(KindNotFound, "order_not_found", "order not found")
+	ErrWarehouseNotFound = newError(KindNotFound, "warehouse_not_found", "warehouse not found")
+	ErrAPIKeyNotFound    = newError(KindNotFound, "api_key_not_found", "API key not found")
+	ErrRoleNotFound      = newError(KindNotFound, "role_not_found", "role not found")
+
+	ErrInvalidTransition  = newError(KindConflict, "invalid_transition", "invalid order status transition")
+	ErrProductInUse       = newError(KindConflict, "product_in_use", "product is referenced by existing orders")
+	ErrDuplicateSKU       = newError(KindConflict, "duplicate_sku", "a product with this SKU already exists")
+	ErrReservationExpired = newError(KindConflict, "reservation_expired", "the order's stock reservation has expired")
+	ErrDuplicateWarehouse = newError(KindConflict, "duplicate_warehouse", "a warehouse with this code already exists")
+	ErrBackorderQueueFull = newError(KindConflict, "backorder_queue_full", "the product's backorder queue is full")
+
+	ErrIdempotencyKeyReused = newError(KindUnprocessable, "idempotency_key_reused", "idempotency key was already used with a different request")
+	ErrCurrencyMismatch     = newError(KindUnprocessable, "currency_mismatch", "order lines must share one currency")
+	ErrOrderQuantityRule    = newError(KindUnprocessable, "order_quantity_rule", "order quantity breaks the product's quantity rules")
+	ErrPurchaseLimit        = newError(KindUnprocessable, "purchase_limit_exceeded", "order exceeds the product's per-user purchase limit")
+
+	ErrSearchUnavailable = newError(KindUnavailable, "search_unavailable", "product search is not available")
+
+	ErrUnauthenticated    = newError(KindUnauthenticated, "unauthenticated", "authentication is required")
+	ErrInvalidCredentials = newError(KindUnauthenticated, "invalid_credentials", "the credentials are invalid or expired")
+
+	ErrInsufficientScope = newError(KindForbidden, "insufficient_scope", "the credentials do not grant access to this resource")
+	ErrPermissionDenied  = newError(KindForbidden, "permission_denied", "you do not have permission to perform this action")
+)
+
+func newError(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches the error itself and the sentinel it was derived from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e == t || (e.sentinel != nil && e.sentinel == t)
+}
+
+// Withf returns a copy of the sentinel with a more specific message.
+func (e *Error) Withf(format string, args ...interface{}) *Error {
+	return &Error{
+		Kind:     e.Kind,
+		Code:     e.Code,
+		Message:  fmt.Sprintf(format, args...),
+		Fields:   e.Fields,
+		sentinel: e.root(),
+	}
+}
+
+// Summary returns the general message of the sentinel e derives from, which
+// is the same for every error with e's code.
+func (e *Error) Summary() string {
+	return e.root().Message
+}
+
+func (e *Error) root() *Error {
+	if e.sentinel != nil {
+		return e.sentinel
+	}
+	return e
+}
+
+// NewValidationError reports one or more invalid fields.
+func NewValidationError(fields ...FieldError) *Error {
+	err := ErrValidation.Withf("%s", ErrValidation.Message)
+	err.Fields = fields
+	if len(fields) == 1 {
+		err.Message = fields[0].Message
+	}
+	return err
+}
+
+// Invalid is shorthand for a validation error on a single field.
+func Invalid(field, code, message string) *Error {
+	return NewValidationError(FieldError{Field: field, Code: code, Message: message})
+}
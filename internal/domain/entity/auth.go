@@ -0,0 +1,136 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scopes grant access to a group of routes. Read scopes cover the GET routes
+// of a resource and write scopes everything that changes it.
+const (
+	ScopeProductsRead    = "products:read"
+	ScopeProductsWrite   = "products:write"
+	ScopeStockRead       = "stock:read"
+	ScopeStockWrite      = "stock:write"
+	ScopeWarehousesRead  = "warehouses:read"
+	ScopeWarehousesWrite = "warehouses:write"
+	ScopeOrdersRead      = "orders:read"
+	ScopeOrdersWrite     = "orders:write"
+)
+
+// Scopes lists every scope a credential may be granted.
+var Scopes = []string{
+	ScopeProductsRead, ScopeProductsWrite,
+	ScopeStockRead, ScopeStockWrite,
+	ScopeWarehousesRead, ScopeWarehousesWrite,
+	ScopeOrdersRead, ScopeOrdersWrite,
+}
+
+// ValidScope reports whether scope is one of Scopes.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// Methods a principal may authenticate with.
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Permissions a role may grant. Scopes limit what a credential may be used
+// for; permissions limit what its subject may do. The resource permissions
+// share their scope's name; orders distinguish the caller's own orders from
+// everyone's.
+const (
+	PermProductsRead    = ScopeProductsRead
+	PermProductsWrite   = ScopeProductsWrite
+	PermStockRead       = ScopeStockRead
+	PermStockWrite      = ScopeStockWrite
+	PermWarehousesRead  = ScopeWarehousesRead
+	PermWarehousesWrite = ScopeWarehousesWrite
+	// PermOrdersCreate places orders for the caller themselves.
+	PermOrdersCreate    = "orders:create"
+	PermOrdersReadOwn   = "orders:read:own"
+	PermOrdersReadAll   = "orders:read:all"
+	PermOrdersCancelOwn = "orders:cancel:own"
+	// PermOrdersManage places orders for other users, completes orders and
+	// cancels anyone's.
+	PermOrdersManage = "orders:manage"
+	// PermRolesManage assigns and unassigns roles.
+	PermRolesManage = "roles:manage"
+)
+
+// Built-in roles. A subject without an assigned role is a customer.
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+	RoleAdmin    = "admin"
+)
+
+// Role is a named set of permissions assigned to subjects.
+type Role struct {
+	Name        string   `json:"name" db:"name"`
+	Description string   `json:"description" db:"description"`
+	Permissions []string `json:"permissions" db:"-"`
+}
+
+// RoleAssignment grants a role to a subject.
+type RoleAssignment struct {
+	Subject   string    `json:"subject" db:"subject"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Principal is the authenticated caller of a request: the subject its
+// credentials were issued to and the scopes they grant.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Scopes  []string `json:"scopes"`
+	Method  string   `json:"method"`
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// APIKeyPrefix starts every API key, so keys can be told apart from JWTs and
+// found by secret scanners.
+const APIKeyPrefix = "pos_"
+
+// APIKey is a static credential issued to a subject. Only the SHA-256 hash of
+// the key is stored; Prefix keeps its first characters so a key can be
+// recognised in listings. A key is usable until it expires or is revoked.
+type APIKey struct {
+	ID        int        `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Subject   string     `json:"subject" db:"subject"`
+	Scopes    []string   `json:"scopes" db:"scopes"`
+	Prefix    string     `json:"prefix" db:"prefix"`
+	KeyHash   string     `json:"-" db:"key_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Active reports whether the key may be used at now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by. Keys are
+// long random strings, so an unsalted hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes splits a space or comma separated list of scopes.
+func ParseScopes(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+}
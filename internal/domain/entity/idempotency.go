@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// IdempotencyRecord stores the outcome of a request made with an
+// Idempotency-Key header so that retries can be answered with the original
+// response. A record without a StatusCode is still in progress.
+type IdempotencyRecord struct {
+	Key          string    `db:"key"`
+	Scope        string    `db:"scope"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   int       `db:"status_code"`
+	ContentType  string    `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// Completed reports whether the original request has finished and its
+// response was recorded.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -1,19 +1,105 @@
 package entity
 
-import "time"
+import (
+	"time"
 
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+)
+
+// Order is an order header with one or more lines. RequestFingerprint hashes
+// the request that created it so a reused idempotency key can be checked
+// against the original payload. Currency and Total are derived from the items
+// by CalculateTotals and are not stored. ReservedUntil is when the stock held
+// for a pending order is released; orders placed before reservations existed
+// have none and consumed their stock when they were created. A backordered
+// order holds no stock and has no ReservedUntil until it is filled.
 type Order struct {
-	ID             int       `json:"id" db:"id"`
-	ProductID      int       `json:"product_id" db:"product_id"`
-	UserID         string    `json:"user_id" db:"user_id"`
-	Quantity       int       `json:"quantity" db:"quantity"`
-	Status         string    `json:"status" db:"status"`
-	IdempotencyKey string    `json:"idempotency_key,omitempty" db:"idempotency_key"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	ID                 int          `json:"id" db:"id"`
+	UserID             string       `json:"user_id" db:"user_id"`
+	Status             string       `json:"status" db:"status"`
+	IdempotencyKey     string       `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	RequestFingerprint string       `json:"-" db:"request_fingerprint"`
+	CreatedAt          time.Time    `json:"created_at" db:"created_at"`
+	ReservedUntil      *time.Time   `json:"reserved_until,omitempty" db:"reserved_until"`
+	Items              []*OrderItem `json:"items"`
+	Currency           string       `json:"currency" db:"-"`
+	Total              int64        `json:"total" db:"-"`
+}
+
+// OrderItem is a single product line of an order. UnitPrice and Currency are
+// copied from the product when the order is placed, so later price changes do
+// not alter existing orders. WarehouseID is the warehouse the line was
+// allocated to, or 0 while its order is backordered.
+type OrderItem struct {
+	ID          int    `json:"id" db:"id"`
+	OrderID     int    `json:"order_id" db:"order_id"`
+	ProductID   int    `json:"product_id" db:"product_id"`
+	WarehouseID int    `json:"warehouse_id" db:"warehouse_id"`
+	Quantity    int    `json:"quantity" db:"quantity"`
+	UnitPrice   int64  `json:"unit_price" db:"unit_price"`
+	Currency    string `json:"currency" db:"currency"`
+	Subtotal    int64  `json:"subtotal" db:"-"`
+}
+
+// CalculateTotals fills in each item's Subtotal and the order's Currency and
+// Total. All items of an order share one currency.
+func (o *Order) CalculateTotals() {
+	o.Total = 0
+	for _, item := range o.Items {
+		item.Subtotal = item.UnitPrice * int64(item.Quantity)
+		o.Total += item.Subtotal
+		o.Currency = item.Currency
+	}
+}
+
+// Allocated reports whether the line has been allocated to a warehouse, and so
+// holds or has taken stock there.
+func (i *OrderItem) Allocated() bool {
+	return i.WarehouseID != 0
+}
+
+// HoldsReservation reports whether the order's stock is held rather than
+// already taken from the products.
+func (o *Order) HoldsReservation() bool {
+	return o.ReservedUntil != nil
+}
+
+// ReservationExpired reports whether the order's hold has lapsed at now.
+func (o *Order) ReservationExpired(now time.Time) bool {
+	return o.ReservedUntil != nil && !now.Before(*o.ReservedUntil)
 }
 
 const (
-	OrderStatusPending   = "pending"
-	OrderStatusCompleted = "completed"
-	OrderStatusCancelled = "cancelled"
+	OrderStatusBackordered = "backordered"
+	OrderStatusPending     = "pending"
+	OrderStatusCompleted   = "completed"
+	OrderStatusCancelled   = "cancelled"
 )
+
+// orderTransitions lists the statuses each status may move to. A backorder
+// becomes pending once its stock is allocated. Completed and cancelled are
+// terminal.
+var orderTransitions = map[string][]string{
+	OrderStatusBackordered: {OrderStatusPending, OrderStatusCancelled},
+	OrderStatusPending:     {OrderStatusCompleted, OrderStatusCancelled},
+}
+
+// CanTransitionTo reports whether the order may move to status.
+func (o *Order) CanTransitionTo(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo moves the order to status, or returns
+// domainerr.ErrInvalidTransition if the move is not allowed.
+func (o *Order) TransitionTo(status string) error {
+	if !o.CanTransitionTo(status) {
+		return domainerr.ErrInvalidTransition.Withf("order %d cannot move from %s to %s", o.ID, o.Status, status)
+	}
+	o.Status = status
+	return nil
+}
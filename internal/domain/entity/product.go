@@ -1,11 +1,182 @@
 package entity
 
-import "time"
+import (
+	"fmt"
+	"time"
 
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+)
+
+// DefaultCurrency is used when a product is created without a currency.
+const DefaultCurrency = "USD"
+
+// Product prices are integer minor units (e.g. cents) of Currency, an
+// ISO 4217 code. SKU is optional but unique, ignoring case, when set.
+// Stock is the quantity on hand, Reserved the part of it held for pending
+// orders and Available what is left to sell. A product with a
+// ReorderThreshold is low on stock once Available is at or below it. A product
+// with AllowBackorder accepts orders beyond its available stock as backorders,
+// at most MaxBackorderDepth of them at a time when that is set. Its
+// OrderQuantityRules limit how much of it may be ordered.
 type Product struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Stock     int       `json:"stock" db:"stock"`
+	ID                int    `json:"id" db:"id"`
+	SKU               string `json:"sku,omitempty" db:"sku"`
+	Name              string `json:"name" db:"name"`
+	Description       string `json:"description" db:"description"`
+	Category          string `json:"category" db:"category"`
+	Barcode           string `json:"barcode,omitempty" db:"barcode"`
+	Stock             int    `json:"stock" db:"stock"`
+	Reserved          int    `json:"reserved" db:"reserved"`
+	Available         int    `json:"available" db:"-"`
+	ReorderThreshold  *int   `json:"reorder_threshold" db:"reorder_threshold"`
+	AllowBackorder    bool   `json:"allow_backorder" db:"allow_backorder"`
+	MaxBackorderDepth *int   `json:"max_backorder_depth" db:"max_backorder_depth"`
+	OrderQuantityRules
+	Price     int64     `json:"price" db:"price"`
+	Currency  string    `json:"currency" db:"currency"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// CalculateAvailable fills in Available from Stock and Reserved.
+func (p *Product) CalculateAvailable() {
+	p.Available = p.Stock - p.Reserved
+}
+
+// OrderQuantityRules restrict the quantity of a product on one order and the
+// total one user may order within a rolling window. Nil fields are
+// unrestricted; MaxPerUser and MaxPerUserWindowSeconds are set together.
+type OrderQuantityRules struct {
+	MinOrderQuantity        *int `json:"min_order_quantity" db:"min_order_quantity" validate:"omitnil,min=1"`
+	MaxOrderQuantity        *int `json:"max_order_quantity" db:"max_order_quantity" validate:"omitnil,min=1"`
+	OrderQuantityStep       *int `json:"order_quantity_step" db:"order_quantity_step" validate:"omitnil,min=1"`
+	MaxPerUser              *int `json:"max_per_user" db:"max_per_user" validate:"omitnil,min=1"`
+	MaxPerUserWindowSeconds *int `json:"max_per_user_window_seconds" db:"max_per_user_window_seconds" validate:"omitnil,min=1"`
+}
+
+// Validate checks that the rules are consistent with each other.
+func (r OrderQuantityRules) Validate() error {
+	for _, rule := range []struct {
+		field string
+		value *int
+	}{
+		{"min_order_quantity", r.MinOrderQuantity},
+		{"max_order_quantity", r.MaxOrderQuantity},
+		{"order_quantity_step", r.OrderQuantityStep},
+		{"max_per_user", r.MaxPerUser},
+		{"max_per_user_window_seconds", r.MaxPerUserWindowSeconds},
+	} {
+		if rule.value != nil && *rule.value < 1 {
+			return domainerr.Invalid(rule.field, "min", fmt.Sprintf("%s must be at least 1", rule.field))
+		}
+	}
+
+	if r.MinOrderQuantity != nil && r.MaxOrderQuantity != nil && *r.MaxOrderQuantity < *r.MinOrderQuantity {
+		return domainerr.Invalid("max_order_quantity", "min", "max_order_quantity must not be less than min_order_quantity")
+	}
+	if (r.MaxPerUser == nil) != (r.MaxPerUserWindowSeconds == nil) {
+		return domainerr.Invalid("max_per_user_window_seconds", "required", "max_per_user and max_per_user_window_seconds must be set together")
+	}
+	return nil
+}
+
+// CheckQuantity returns domainerr.ErrOrderQuantityRule if quantity may not be
+// ordered on one order of productID.
+func (r OrderQuantityRules) CheckQuantity(productID, quantity int) error {
+	if r.MinOrderQuantity != nil && quantity < *r.MinOrderQuantity {
+		return domainerr.ErrOrderQuantityRule.Withf("product %d must be ordered in quantities of at least %d", productID, *r.MinOrderQuantity)
+	}
+	if r.MaxOrderQuantity != nil && quantity > *r.MaxOrderQuantity {
+		return domainerr.ErrOrderQuantityRule.Withf("product %d may be ordered in quantities of at most %d", productID, *r.MaxOrderQuantity)
+	}
+	if r.OrderQuantityStep != nil && quantity%*r.OrderQuantityStep != 0 {
+		return domainerr.ErrOrderQuantityRule.Withf("product %d must be ordered in multiples of %d", productID, *r.OrderQuantityStep)
+	}
+	return nil
+}
+
+// PerUserWindow returns the rolling window of the per-user limit, and false
+// if the product has none.
+func (r OrderQuantityRules) PerUserWindow() (time.Duration, bool) {
+	if r.MaxPerUser == nil || r.MaxPerUserWindowSeconds == nil {
+		return 0, false
+	}
+	return time.Duration(*r.MaxPerUserWindowSeconds) * time.Second, true
+}
+
+// BackorderQueueFull reports whether a product with queued backorders already
+// waiting can take no more.
+func (p *Product) BackorderQueueFull(queued int) bool {
+	return p.MaxBackorderDepth != nil && queued >= *p.MaxBackorderDepth
+}
+
+// LowStock reports whether the product has a reorder threshold and its
+// available stock is at or below it.
+func (p *Product) LowStock() bool {
+	return p.ReorderThreshold != nil && p.Available <= *p.ReorderThreshold
+}
+
+// CrossedReorderThreshold reports whether a change from previousAvailable to
+// the current available stock took the product down to its reorder threshold.
+// A product already at or below its threshold does not cross it again.
+func (p *Product) CrossedReorderThreshold(previousAvailable int) bool {
+	return p.LowStock() && previousAvailable > *p.ReorderThreshold
+}
+
+// ProductSearchResult is a product matched by a full-text search. Higher
+// scores are better matches; Snippet is an excerpt of the best matching field
+// with the matched terms wrapped in <mark> tags.
+type ProductSearchResult struct {
+	Product *Product `json:"product"`
+	Score   float64  `json:"score"`
+	Snippet string   `json:"snippet"`
+}
+
+// ValidCurrency reports whether code has the shape of an ISO 4217 alphabetic
+// code: three upper-case letters.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxSKULength bounds the length of a SKU.
+const MaxSKULength = 64
+
+// ValidSKU reports whether sku is 1 to MaxSKULength letters, digits, '-', '_'
+// or '.'.
+func ValidSKU(sku string) bool {
+	if sku == "" || len(sku) > MaxSKULength {
+		return false
+	}
+	for _, r := range sku {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ValidBarcode reports whether code looks like a GTIN: 8, 12, 13 or 14 digits.
+func ValidBarcode(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// EventLowStock names the event raised when a product's available stock falls
+// to its reorder threshold.
+const EventLowStock = "product.low_stock"
+
+// LowStockAlert is raised once each time a stock change takes a product from
+// above its reorder threshold to at or below it.
+type LowStockAlert struct {
+	Event             string    `json:"event"`
+	ProductID         int       `json:"product_id"`
+	SKU               string    `json:"sku,omitempty"`
+	Name              string    `json:"name"`
+	Stock             int       `json:"stock"`
+	Reserved          int       `json:"reserved"`
+	Available         int       `json:"available"`
+	PreviousAvailable int       `json:"previous_available"`
+	ReorderThreshold  int       `json:"reorder_threshold"`
+	OccurredAt        time.Time `json:"occurred_at"`
+}
+
+// NewLowStockAlert describes product having just crossed its reorder
+// threshold from previousAvailable.
+func NewLowStockAlert(product *Product, previousAvailable int) *LowStockAlert {
+	return &LowStockAlert{
+		Event:             EventLowStock,
+		ProductID:         product.ID,
+		SKU:               product.SKU,
+		Name:              product.Name,
+		Stock:             product.Stock,
+		Reserved:          product.Reserved,
+		Available:         product.Available,
+		PreviousAvailable: previousAvailable,
+		ReorderThreshold:  *product.ReorderThreshold,
+		OccurredAt:        time.Now(),
+	}
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// Reasons recorded on stock movements.
+const (
+	StockReasonOpeningBalance = "opening_balance"
+	StockReasonOrder          = "order"
+	StockReasonCancellation   = "cancellation"
+	StockReasonRestock        = "restock"
+	StockReasonAdjustment     = "adjustment"
+	StockReasonTransfer       = "transfer"
+)
+
+// ActorSystem is recorded on stock movements not made on behalf of a user.
+const ActorSystem = "system"
+
+// StockMovement is one entry in the append-only stock ledger. Balance is the
+// product's stock in the warehouse after Delta was applied; Note is the
+// free-text explanation given for restocks, adjustments and transfers.
+type StockMovement struct {
+	ID          int       `json:"id" db:"id"`
+	ProductID   int       `json:"product_id" db:"product_id"`
+	WarehouseID int       `json:"warehouse_id" db:"warehouse_id"`
+	Delta       int       `json:"delta" db:"delta"`
+	Balance     int       `json:"balance" db:"balance"`
+	Reason      string    `json:"reason" db:"reason"`
+	ReferenceID string    `json:"reference_id,omitempty" db:"reference_id"`
+	Note        string    `json:"note,omitempty" db:"note"`
+	Actor       string    `json:"actor" db:"actor"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// StockDiscrepancy reports stock that disagrees with the ledger. A warehouse
+// discrepancy compares a warehouse's stock of a product with that
+// warehouse's movements. A product discrepancy (WarehouseID 0) compares the
+// product's total stock with all of its movements and with the sum of its
+// warehouse stock.
+type StockDiscrepancy struct {
+	ProductID      int  `json:"product_id"`
+	WarehouseID    int  `json:"warehouse_id,omitempty"`
+	Stock          int  `json:"stock"`
+	LedgerSum      int  `json:"ledger_sum"`
+	LedgerBalance  *int `json:"ledger_balance,omitempty"`
+	WarehouseTotal *int `json:"warehouse_total,omitempty"`
+}
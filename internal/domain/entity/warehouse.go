@@ -0,0 +1,59 @@
+package entity
+
+import "time"
+
+// Warehouse is a location stock is held and shipped from. Codes are unique,
+// ignoring case. The warehouse with the lowest ID is the primary warehouse,
+// used when a stock change does not name one.
+type Warehouse struct {
+	ID        int       `json:"id" db:"id"`
+	Code      string    `json:"code" db:"code"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WarehouseStock is a product's stock level in one warehouse. Stock,
+// Reserved and Available have the same meaning as on Product, which holds
+// their totals across warehouses.
+type WarehouseStock struct {
+	WarehouseID   int    `json:"warehouse_id" db:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code" db:"-"`
+	ProductID     int    `json:"product_id" db:"product_id"`
+	Stock         int    `json:"stock" db:"stock"`
+	Reserved      int    `json:"reserved" db:"reserved"`
+	Available     int    `json:"available" db:"-"`
+}
+
+// StockTransfer moves a quantity of a product from one warehouse to another.
+// It is recorded in the ledger as a pair of transfer movements referencing it.
+type StockTransfer struct {
+	ID              int       `json:"id" db:"id"`
+	ProductID       int       `json:"product_id" db:"product_id"`
+	FromWarehouseID int       `json:"from_warehouse_id" db:"from_warehouse_id"`
+	ToWarehouseID   int       `json:"to_warehouse_id" db:"to_warehouse_id"`
+	Quantity        int       `json:"quantity" db:"quantity"`
+	Note            string    `json:"note,omitempty" db:"note"`
+	Actor           string    `json:"actor" db:"actor"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// MaxWarehouseCodeLength bounds the length of a warehouse code.
+const MaxWarehouseCodeLength = 32
+
+// ValidWarehouseCode reports whether code is 1 to MaxWarehouseCodeLength
+// letters, digits, '-' or '_'.
+func ValidWarehouseCode(code string) bool {
+	if code == "" || len(code) > MaxWarehouseCodeLength {
+		return false
+	}
+	for _, r := range code {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
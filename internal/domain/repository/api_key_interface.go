@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	// GetByHash returns the key with the given hash, whether or not it is
+	// still active.
+	GetByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	GetAll(ctx context.Context) ([]*entity.APIKey, error)
+	// Revoke marks a key revoked at the given time. Revoking a key twice keeps
+	// the first time.
+	Revoke(ctx context.Context, id int, at time.Time) error
+}
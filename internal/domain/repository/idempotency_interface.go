@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type IdempotencyRepository interface {
+	// Reserve claims record.Key within record.Scope. If a live record already
+	// holds the key it is returned instead and nothing is written.
+	Reserve(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope string, key string, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, scope string, key string) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 )
@@ -9,6 +10,33 @@ import (
 type OrderRepository interface {
 	Create(ctx context.Context, order *entity.Order) error
 	GetByID(ctx context.Context, id int) (*entity.Order, error)
-	GetByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error)
+	GetByIdempotencyKey(ctx context.Context, userID string, key string) (*entity.Order, error)
 	GetAll(ctx context.Context) ([]*entity.Order, error)
+	List(ctx context.Context, filter OrderFilter, page PageRequest) ([]*entity.Order, string, error)
+	UpdateStatus(ctx context.Context, id int, fromStatus string, toStatus string) error
+	// ListExpiredReservations returns the IDs of up to limit pending orders
+	// whose stock reservation lapsed at or before now, oldest first.
+	ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]int, error)
+	// ListBackorders returns the backordered orders with a line for the
+	// product, with their items, in queue order (oldest first).
+	ListBackorders(ctx context.Context, productID int) ([]*entity.Order, error)
+	CountBackorders(ctx context.Context, productID int) (int, error)
+	// SumUserQuantity returns the quantity of the product on the user's
+	// orders created at or after since, not counting cancelled orders.
+	SumUserQuantity(ctx context.Context, userID string, productID int, since time.Time) (int, error)
+	// PromoteBackorder moves a backordered order to pending, saving its
+	// ReservedUntil and the warehouse each item was allocated to. It fails
+	// with domainerr.ErrInvalidTransition if the order is no longer
+	// backordered.
+	PromoteBackorder(ctx context.Context, order *entity.Order) error
+}
+
+// OrderFilter narrows the orders returned by OrderRepository.List. Zero-valued
+// fields are ignored.
+type OrderFilter struct {
+	UserID        string
+	ProductID     int
+	Status        string
+	CreatedFrom   time.Time // inclusive
+	CreatedBefore time.Time // exclusive
 }
@@ -0,0 +1,25 @@
+package repository
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// PageRequest describes one page of a keyset-paginated listing.
+type PageRequest struct {
+	Limit  int    // clamped to [1, MaxPageLimit]; zero means DefaultPageLimit
+	Cursor string // opaque cursor returned with the previous page
+	Sort   string // whitelisted field name, prefixed with "-" for descending
+}
+
+// PageLimit returns the effective page size.
+func (p PageRequest) PageLimit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	default:
+		return p.Limit
+	}
+}
@@ -9,7 +9,29 @@ import (
 type ProductRepository interface {
 	Create(ctx context.Context, product *entity.Product) error
 	GetbyID(ctx context.Context, id int) (*entity.Product, error)
+	GetBySKU(ctx context.Context, sku string) (*entity.Product, error)
 	GetAll(ctx context.Context) ([]*entity.Product, error)
+	List(ctx context.Context, page PageRequest) ([]*entity.Product, string, error)
+	// ListLowStock lists products whose available stock is at or below their
+	// reorder threshold, by default the least available first.
+	ListLowStock(ctx context.Context, page PageRequest) ([]*entity.Product, string, error)
+	Search(ctx context.Context, query string, limit int, offset int) ([]*entity.ProductSearchResult, error)
 	Update(ctx context.Context, product *entity.Product) error
-	UpdateStock(ctx context.Context, productID int, newStock int) error
+	Delete(ctx context.Context, id int) error
+	// Stock changes are relative, apply to one warehouse and are recorded in
+	// the stock ledger as described by change. AdjustStock takes a signed
+	// delta and returns the recorded movement.
+	AdjustStock(ctx context.Context, productID int, warehouseID int, delta int, change StockChange) (*entity.StockMovement, error)
+	IncrementStock(ctx context.Context, productID int, warehouseID int, quantity int, change StockChange) error
+	// Reservations hold available stock in a warehouse for pending orders
+	// without changing the stock on hand. CommitReservedStock takes a held
+	// quantity out of stock, recording it in the ledger; ReleaseReservedStock
+	// makes it available again.
+	ReserveStock(ctx context.Context, productID int, warehouseID int, quantity int) error
+	CommitReservedStock(ctx context.Context, productID int, warehouseID int, quantity int, change StockChange) error
+	ReleaseReservedStock(ctx context.Context, productID int, warehouseID int, quantity int) error
+	// TransferStock moves available stock between the transfer's warehouses,
+	// recording the transfer and its two ledger movements together. The
+	// transfer's ID and CreatedAt are set on success.
+	TransferStock(ctx context.Context, transfer *entity.StockTransfer) error
 }
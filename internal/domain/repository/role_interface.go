@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type RoleRepository interface {
+	// GetAll returns every role with its permissions, ordered by name.
+	GetAll(ctx context.Context) ([]*entity.Role, error)
+	// GetSubjectRoles returns the names of the roles assigned to subject.
+	GetSubjectRoles(ctx context.Context, subject string) ([]string, error)
+	// GetPermissions returns the permissions granted by any of the roles.
+	GetPermissions(ctx context.Context, roles ...string) ([]string, error)
+	// GetAssignments returns every role assignment, ordered by subject.
+	GetAssignments(ctx context.Context) ([]*entity.RoleAssignment, error)
+	// Assign grants a role to a subject; assigning it again changes nothing.
+	Assign(ctx context.Context, assignment *entity.RoleAssignment) error
+	Unassign(ctx context.Context, subject, role string) error
+}
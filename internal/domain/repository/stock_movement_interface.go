@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+// StockChange describes why stock is changing; it becomes a stock movement
+// written in the same transaction as the change itself.
+type StockChange struct {
+	Reason      string
+	ReferenceID string
+	Note        string
+	Actor       string
+}
+
+type StockMovementRepository interface {
+	ListByProduct(ctx context.Context, productID int, page PageRequest) ([]*entity.StockMovement, string, error)
+	// Verify returns every product whose stock differs from the sum of its
+	// movements or from the balance of its latest movement.
+	Verify(ctx context.Context) ([]entity.StockDiscrepancy, error)
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// TransactionManager runs a unit of work atomically. Repository calls made with
+// the context passed to fn participate in the same transaction.
+type TransactionManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+type WarehouseRepository interface {
+	Create(ctx context.Context, warehouse *entity.Warehouse) error
+	GetByID(ctx context.Context, id int) (*entity.Warehouse, error)
+	// GetPrimary returns the warehouse with the lowest ID.
+	GetPrimary(ctx context.Context) (*entity.Warehouse, error)
+	GetAll(ctx context.Context) ([]*entity.Warehouse, error)
+	// ListStock returns a product's stock level in every warehouse, ordered by
+	// warehouse ID. Warehouses that have never held the product report zero.
+	ListStock(ctx context.Context, productID int) ([]*entity.WarehouseStock, error)
+}
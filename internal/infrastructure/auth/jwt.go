@@ -0,0 +1,300 @@
+// Package auth verifies JSON Web Tokens against a local key set. Only the
+// HS256 and RS256 algorithms are accepted, each with keys of its own type, so
+// a token cannot pick an algorithm its key was not issued for.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// clockSkew is how far exp and nbf may be off before a token is rejected.
+const clockSkew = time.Minute
+
+// Key is a verification key from a JSON Web Key Set: a shared secret for
+// HS256 or an RSA public key for RS256.
+type Key struct {
+	ID     string
+	Alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// KeySet is the keys tokens may be signed with.
+type KeySet struct {
+	keys []*Key
+}
+
+// jwk is the subset of RFC 7517 members the key set is read from.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// LoadKeySet reads a JSON Web Key Set from a file.
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key set: %w", err)
+	}
+	return ParseKeySet(data)
+}
+
+// ParseKeySet parses a JSON Web Key Set. "oct" keys verify HS256 tokens and
+// "RSA" keys RS256 tokens; keys for encryption are skipped.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse key set: %w", err)
+	}
+
+	set := &KeySet{}
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d in key set: %w", i, err)
+		}
+		set.keys = append(set.keys, key)
+	}
+	if len(set.keys) == 0 {
+		return nil, errors.New("key set has no signing keys")
+	}
+	return set, nil
+}
+
+func parseKey(k jwk) (*Key, error) {
+	switch k.Kty {
+	case "oct":
+		if k.Alg != "" && k.Alg != AlgHS256 {
+			return nil, fmt.Errorf("unsupported algorithm %q for an oct key", k.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("invalid k: %w", err)
+		}
+		// RFC 7518 requires a key at least as long as the hash
+		if len(secret) < sha256.Size {
+			return nil, errors.New("HS256 keys must be at least 32 bytes")
+		}
+		return &Key{ID: k.Kid, Alg: AlgHS256, secret: secret}, nil
+
+	case "RSA":
+		if k.Alg != "" && k.Alg != AlgRS256 {
+			return nil, fmt.Errorf("unsupported algorithm %q for an RSA key", k.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid e: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &Key{ID: k.Kid, Alg: AlgRS256, public: public}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// VerifierConfig controls which tokens a verifier accepts. An empty Issuer or
+// Audience is not checked.
+type VerifierConfig struct {
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+}
+
+type jwtVerifier struct {
+	config VerifierConfig
+	now    func() time.Time
+}
+
+func NewJWTVerifier(config VerifierConfig) output.TokenVerifier {
+	return &jwtVerifier{config: config, now: time.Now}
+}
+
+// claims are the registered claims checked and the ones the principal is
+// read from. Scopes are taken from "scope", a space separated string as in
+// RFC 8693, or "scp", a list.
+type claims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       json.RawMessage `json:"scp"`
+}
+
+// Verify implements output.TokenVerifier.
+func (v *jwtVerifier) Verify(token string) (*entity.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("the token is malformed")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("the token header is malformed")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("the token signature is malformed")
+	}
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return nil, invalidToken("the token signature is invalid")
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, invalidToken("the token claims are malformed")
+	}
+	if err := v.checkClaims(&c); err != nil {
+		return nil, err
+	}
+
+	scopes := entity.ParseScopes(c.Scope)
+	if len(c.Scp) > 0 {
+		var list []string
+		if err := json.Unmarshal(c.Scp, &list); err != nil {
+			var s string
+			if err := json.Unmarshal(c.Scp, &s); err != nil {
+				return nil, invalidToken("the scp claim is malformed")
+			}
+			list = entity.ParseScopes(s)
+		}
+		scopes = append(scopes, list...)
+	}
+
+	return &entity.Principal{
+		Subject: c.Subject,
+		Scopes:  scopes,
+		Method:  entity.AuthMethodJWT,
+	}, nil
+}
+
+// verifySignature checks the signature with the key named by kid or, without
+// one, with every key for the algorithm.
+func (v *jwtVerifier) verifySignature(alg, kid, signed string, signature []byte) bool {
+	if alg != AlgHS256 && alg != AlgRS256 {
+		return false
+	}
+	for _, key := range v.config.Keys.keys {
+		if key.Alg != alg || (kid != "" && key.ID != kid) {
+			continue
+		}
+		if key.verify([]byte(signed), signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Key) verify(signed, signature []byte) bool {
+	switch k.Alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case AlgRS256:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+func (v *jwtVerifier) checkClaims(c *claims) error {
+	now := v.now()
+	if c.Subject == "" {
+		return invalidToken("the token has no subject")
+	}
+	if c.ExpiresAt == nil {
+		return invalidToken("the token has no expiry")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(clockSkew)) {
+		return invalidToken("the token has expired")
+	}
+	if c.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*c.NotBefore)) {
+		return invalidToken("the token is not valid yet")
+	}
+	if v.config.Issuer != "" && c.Issuer != v.config.Issuer {
+		return invalidToken("the token was issued by %q", c.Issuer)
+	}
+	if v.config.Audience != "" && !hasAudience(c.Audience, v.config.Audience) {
+		return invalidToken("the token is not intended for this service")
+	}
+	return nil
+}
+
+// hasAudience reports whether the aud claim, a string or a list of strings,
+// contains audience.
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return false
+	}
+	return slices.Contains(list, audience)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
+
+func invalidToken(format string, args ...interface{}) error {
+	return domainerr.ErrInvalidCredentials.Withf(format, args...)
+}
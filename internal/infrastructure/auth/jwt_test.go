@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+)
+
+var (
+	testNow    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func sign(t *testing.T, header, claims map[string]interface{}, signer func(signed []byte) []byte) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signer([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet := fmt.Sprintf(`{"keys": [
+		{"kty": "oct", "kid": "shared", "k": %q},
+		{"kty": "RSA", "kid": "rsa", "n": %q, "e": %q},
+		{"kty": "RSA", "use": "enc", "n": "", "e": ""}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(testSecret),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()))
+	keys, err := ParseKeySet([]byte(keySet))
+	if err != nil {
+		t.Fatalf("ParseKeySet: %v", err)
+	}
+	verifier := &jwtVerifier{
+		config: VerifierConfig{Keys: keys, Issuer: "https://issuer.example", Audience: "orders-api"},
+		now:    func() time.Time { return testNow },
+	}
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "user-1",
+			"iss":   "https://issuer.example",
+			"aud":   []string{"other", "orders-api"},
+			"exp":   testNow.Add(time.Hour).Unix(),
+			"scope": "orders:read orders:write",
+		}
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	hsHeader := map[string]interface{}{"alg": "HS256", "kid": "shared"}
+	rsHeader := map[string]interface{}{"alg": "RS256", "kid": "rsa"}
+
+	tests := []struct {
+		name   string
+		token  string
+		scopes []string
+	}{
+		{"HS256", sign(t, hsHeader, claims(nil), hs256(testSecret)), []string{"orders:read", "orders:write"}},
+		{"RS256 with scp list", sign(t, rsHeader, claims(map[string]interface{}{"scope": nil, "scp": []string{"products:read"}}), rs256(t, rsaKey)), []string{"products:read"}},
+		{"no kid", sign(t, map[string]interface{}{"alg": "HS256"}, claims(nil), hs256(testSecret)), []string{"orders:read", "orders:write"}},
+		{"within clock skew", sign(t, hsHeader, claims(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()}), hs256(testSecret)), []string{"orders:read", "orders:write"}},
+		{"wrong secret", sign(t, hsHeader, claims(nil), hs256([]byte("another secret of thirty-two bytes"))), nil},
+		{"alg none", sign(t, map[string]interface{}{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), nil},
+		{"HS256 with RSA key", sign(t, map[string]interface{}{"alg": "HS256", "kid": "rsa"}, claims(nil), hs256(rsaKey.N.Bytes())), nil},
+		{"unknown kid", sign(t, map[string]interface{}{"alg": "HS256", "kid": "other"}, claims(nil), hs256(testSecret)), nil},
+		{"expired", sign(t, hsHeader, claims(map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()}), hs256(testSecret)), nil},
+		{"no expiry", sign(t, hsHeader, claims(map[string]interface{}{"exp": nil}), hs256(testSecret)), nil},
+		{"not yet valid", sign(t, hsHeader, claims(map[string]interface{}{"nbf": testNow.Add(time.Hour).Unix()}), hs256(testSecret)), nil},
+		{"no subject", sign(t, hsHeader, claims(map[string]interface{}{"sub": nil}), hs256(testSecret)), nil},
+		{"wrong issuer", sign(t, hsHeader, claims(map[string]interface{}{"iss": "https://evil.example"}), hs256(testSecret)), nil},
+		{"wrong audience", sign(t, hsHeader, claims(map[string]interface{}{"aud": "other"}), hs256(testSecret)), nil},
+		{"malformed", "not-a-token", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(tt.token)
+			if tt.scopes == nil {
+				if !errors.Is(err, domainerr.ErrInvalidCredentials) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if principal.Subject != "user-1" || !slices.Equal(principal.Scopes, tt.scopes) {
+				t.Errorf("Verify() = %+v, want subject user-1 with scopes %v", principal, tt.scopes)
+			}
+		})
+	}
+}
+
+func TestParseKeySetRejectsWeakKeys(t *testing.T) {
+	short := base64.RawURLEncoding.EncodeToString([]byte("too short"))
+	for _, keySet := range []string{
+		`{"keys": []}`,
+		`{"keys": [{"kty": "oct", "k": "` + short + `"}]}`,
+		`{"keys": [{"kty": "oct", "alg": "HS512", "k": "` + base64.RawURLEncoding.EncodeToString(testSecret) + `"}]}`,
+		`{"keys": [{"kty": "RSA", "n": "AQAB", "e": "AQAB"}]}`,
+		`{"keys": [{"kty": "EC", "crv": "P-256"}]}`,
+	} {
+		if _, err := ParseKeySet([]byte(keySet)); err == nil {
+			t.Errorf("ParseKeySet(%s) succeeded, want an error", keySet)
+		}
+	}
+}
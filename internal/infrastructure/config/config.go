@@ -2,11 +2,19 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Idempotency IdempotencyConfig
+	Reservation ReservationConfig
+	Allocation  AllocationConfig
+	Alerts      AlertConfig
+	Validation  ValidationConfig
+	Auth        AuthConfig
 }
 
 type ServerConfig struct {
@@ -15,7 +23,50 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
-	Path string
+	Path        string
+	AutoMigrate bool
+}
+
+type IdempotencyConfig struct {
+	TTL        time.Duration
+	RequireKey bool
+}
+
+// ReservationConfig controls how long pending orders hold their stock and how
+// often lapsed holds are released.
+type ReservationConfig struct {
+	TTL           time.Duration
+	SweepInterval time.Duration
+}
+
+// AllocationConfig names the strategy that picks the warehouse each order
+// line is fulfilled from.
+type AllocationConfig struct {
+	Strategy string
+}
+
+// AlertConfig controls where low-stock alerts are delivered. Without a webhook
+// URL alerts are only logged.
+type AlertConfig struct {
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+}
+
+// ValidationConfig controls request validation. In strict mode request bodies
+// with fields the endpoint does not know are rejected.
+type ValidationConfig struct {
+	Strict bool
+}
+
+// AuthConfig controls authentication. API keys are always accepted when it is
+// enabled; JWTs only when a key set file is configured. An empty issuer or
+// audience is not checked.
+type AuthConfig struct {
+	Enabled     bool
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
 }
 
 func LoadConfig() *Config {
@@ -25,7 +76,33 @@ func LoadConfig() *Config {
 			Host: getEnv("HOST", "0.0.0.0"),
 		},
 		Database: DatabaseConfig{
-			Path: getEnv("DATABASE_PATH", "./ecommerce.db"),
+			Path:        getEnv("DATABASE_PATH", "./ecommerce.db"),
+			AutoMigrate: getEnvBool("DATABASE_AUTO_MIGRATE", true),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:        getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			RequireKey: getEnvBool("IDEMPOTENCY_REQUIRE_KEY", false),
+		},
+		Reservation: ReservationConfig{
+			TTL:           getEnvDuration("RESERVATION_TTL", 15*time.Minute),
+			SweepInterval: getEnvDuration("RESERVATION_SWEEP_INTERVAL", 30*time.Second),
+		},
+		Allocation: AllocationConfig{
+			Strategy: getEnv("ALLOCATION_STRATEGY", "first_fit"),
+		},
+		Alerts: AlertConfig{
+			WebhookURL:     getEnv("LOW_STOCK_WEBHOOK_URL", ""),
+			WebhookSecret:  getEnv("LOW_STOCK_WEBHOOK_SECRET", ""),
+			WebhookTimeout: getEnvDuration("LOW_STOCK_WEBHOOK_TIMEOUT", 5*time.Second),
+		},
+		Validation: ValidationConfig{
+			Strict: getEnvBool("VALIDATION_STRICT", false),
+		},
+		Auth: AuthConfig{
+			Enabled:     getEnvBool("AUTH_ENABLED", true),
+			JWKSFile:    getEnv("AUTH_JWKS_FILE", ""),
+			JWTIssuer:   getEnv("AUTH_JWT_ISSUER", ""),
+			JWTAudience: getEnv("AUTH_JWT_AUDIENCE", ""),
 		},
 	}
 }
@@ -36,3 +113,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -13,8 +14,31 @@ type Database struct {
 	logger logger.Logger
 }
 
+// NewDatabase opens the database and applies any pending migrations.
 func NewDatabase(dbPath string, logger logger.Logger) (*Database, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	database, err := OpenDatabase(dbPath, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.migrate(); err != nil {
+		logger.Error("Failed to migrate database", "error", err)
+		database.DB.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	logger.Info("Database connection established and migrations applied successfully")
+	return database, nil
+}
+
+// OpenDatabase opens the database without touching its schema.
+func OpenDatabase(dbPath string, logger logger.Logger) (*Database, error) {
+	// Writers wait on each other instead of failing with SQLITE_BUSY, and
+	// transactions take the write lock up front so read-then-write units of
+	// work cannot interleave.
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_txlock=immediate", dbPath)
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		logger.Error("Failed to open database", "error", err)
 		return nil, err
@@ -24,53 +48,30 @@ func NewDatabase(dbPath string, logger logger.Logger) (*Database, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	database := &Database{
+	return &Database{
 		DB:     db,
 		logger: logger,
-	}
-
-	if err := database.migrate(); err != nil {
-		logger.Error("Failed to migrate database", "error", err)
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
+	}, nil
+}
 
-	logger.Info("Database connection established and migrations applied successfully")
-	return database, nil
+func (d *Database) Migrator() (*Migrator, error) {
+	return NewMigrator(d.DB, d.logger)
 }
 
 func (d *Database) migrate() error {
 	d.logger.Info("Running database migrations")
 
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS products (
-			id INTEGER PRIMARY KEY AUTOINCREMENT, 
-			name TEXT NOT NULL,
-			stock INTEGER NOT NULL,
-			created_at DATETIME NOT NULL,
-			updated_at DATETIME NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS orders (
-			id INTEGER PRIMARY KEY AUTOINCREMENT, 
-			product_id INTEGER NOT NULL,            
-			user_id TEXT NOT NULL,
-			quantity INTEGER NOT NULL,
-			status TEXT NOT NULL,
-			idempotency_key TEXT UNIQUE,
-			created_at DATETIME NOT NULL,
-			FOREIGN KEY (product_id) REFERENCES products (id)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_orders_idempotency_key ON orders(idempotency_key)`,
-		`CREATE INDEX IF NOT EXISTS idx_orders_product_id ON orders(product_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id)`,
+	migrator, err := d.Migrator()
+	if err != nil {
+		return err
 	}
 
-	for _, query := range queries {
-		if _, err := d.DB.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute migration query: %w", err)
-		}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
 	}
 
-	d.logger.Info("Database migrations completed successfully")
+	d.logger.Info("Database migrations completed successfully", "applied", applied)
 	return nil
 }
 
@@ -0,0 +1,9 @@
+//go:build !sqlite_fts5
+
+package database
+
+// Product search needs SQLite's FTS5 module, which go-sqlite3 only compiles
+// in with the sqlite_fts5 build tag. Without the tag the build stops here
+// rather than producing a server whose search can never work: build with
+// -tags sqlite_fts5, as the Makefile does.
+var _ = build_with_tag_sqlite_fts5
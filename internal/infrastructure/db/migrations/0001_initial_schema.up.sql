@@ -0,0 +1,24 @@
+-- Schema as originally created by Database.migrate. IF NOT EXISTS lets this
+-- adopt databases that predate schema_migrations.
+CREATE TABLE IF NOT EXISTS products (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	stock INTEGER NOT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	user_id TEXT NOT NULL,
+	quantity INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	idempotency_key TEXT UNIQUE,
+	created_at DATETIME NOT NULL,
+	FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_idempotency_key ON orders(idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_orders_product_id ON orders(product_id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
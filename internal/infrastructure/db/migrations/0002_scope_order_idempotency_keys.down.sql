@@ -0,0 +1,21 @@
+-- Fails if two users share an idempotency key, as the old schema cannot hold them.
+CREATE TABLE orders_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	user_id TEXT NOT NULL,
+	quantity INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	idempotency_key TEXT UNIQUE,
+	created_at DATETIME NOT NULL,
+	FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+INSERT INTO orders_old (id, product_id, user_id, quantity, status, idempotency_key, created_at)
+	SELECT id, product_id, user_id, quantity, status, idempotency_key, created_at FROM orders;
+
+DROP TABLE orders;
+ALTER TABLE orders_old RENAME TO orders;
+
+CREATE INDEX idx_orders_idempotency_key ON orders(idempotency_key);
+CREATE INDEX idx_orders_product_id ON orders(product_id);
+CREATE INDEX idx_orders_user_id ON orders(user_id);
@@ -0,0 +1,24 @@
+-- Idempotency keys become unique per user and orders record a fingerprint of
+-- the request that created them. SQLite cannot drop the old column-level
+-- UNIQUE constraint, so the table is rebuilt.
+CREATE TABLE orders_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	user_id TEXT NOT NULL,
+	quantity INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	idempotency_key TEXT,
+	request_fingerprint TEXT,
+	created_at DATETIME NOT NULL,
+	UNIQUE (user_id, idempotency_key),
+	FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+INSERT INTO orders_new (id, product_id, user_id, quantity, status, idempotency_key, created_at)
+	SELECT id, product_id, user_id, quantity, status, idempotency_key, created_at FROM orders;
+
+DROP TABLE orders;
+ALTER TABLE orders_new RENAME TO orders;
+
+CREATE INDEX idx_orders_product_id ON orders(product_id);
+CREATE INDEX idx_orders_user_id ON orders(user_id);
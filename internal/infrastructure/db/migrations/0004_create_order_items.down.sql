@@ -0,0 +1,25 @@
+-- Lossy for multi-line orders: only the lowest product_id line is kept.
+CREATE TABLE orders_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	user_id TEXT NOT NULL,
+	quantity INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	idempotency_key TEXT,
+	request_fingerprint TEXT,
+	created_at DATETIME NOT NULL,
+	UNIQUE (user_id, idempotency_key),
+	FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+INSERT INTO orders_old (id, product_id, user_id, quantity, status, idempotency_key, request_fingerprint, created_at)
+	SELECT o.id, i.product_id, o.user_id, i.quantity, o.status, o.idempotency_key, o.request_fingerprint, o.created_at
+	FROM orders o
+	JOIN order_items i ON i.id = (SELECT id FROM order_items WHERE order_id = o.id ORDER BY product_id LIMIT 1);
+
+DROP TABLE order_items;
+DROP TABLE orders;
+ALTER TABLE orders_old RENAME TO orders;
+
+CREATE INDEX idx_orders_product_id ON orders(product_id);
+CREATE INDEX idx_orders_user_id ON orders(user_id);
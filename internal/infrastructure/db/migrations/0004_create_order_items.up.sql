@@ -0,0 +1,35 @@
+-- Orders become a header with one or more lines. Existing orders are moved to
+-- a single line each and the per-order product_id and quantity columns are
+-- dropped by rebuilding the table.
+CREATE TABLE order_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	quantity INTEGER NOT NULL CHECK (quantity > 0),
+	UNIQUE (order_id, product_id),
+	FOREIGN KEY (order_id) REFERENCES orders (id),
+	FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+INSERT INTO order_items (order_id, product_id, quantity)
+	SELECT id, product_id, quantity FROM orders;
+
+CREATE TABLE orders_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id TEXT NOT NULL,
+	status TEXT NOT NULL,
+	idempotency_key TEXT,
+	request_fingerprint TEXT,
+	created_at DATETIME NOT NULL,
+	UNIQUE (user_id, idempotency_key)
+);
+
+INSERT INTO orders_new (id, user_id, status, idempotency_key, request_fingerprint, created_at)
+	SELECT id, user_id, status, idempotency_key, request_fingerprint, created_at FROM orders;
+
+DROP TABLE orders;
+ALTER TABLE orders_new RENAME TO orders;
+
+CREATE INDEX idx_orders_user_id ON orders(user_id);
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX idx_order_items_product_id ON order_items(product_id);
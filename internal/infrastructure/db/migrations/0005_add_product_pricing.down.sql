@@ -0,0 +1,5 @@
+ALTER TABLE order_items DROP COLUMN currency;
+ALTER TABLE order_items DROP COLUMN unit_price;
+
+ALTER TABLE products DROP COLUMN currency;
+ALTER TABLE products DROP COLUMN price;
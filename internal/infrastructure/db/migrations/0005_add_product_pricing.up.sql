@@ -0,0 +1,8 @@
+-- Prices are stored in minor units (e.g. cents) of an ISO 4217 currency.
+-- Existing products start at 0 and existing order lines get a 0 unit price,
+-- since no price history exists for them.
+ALTER TABLE products ADD COLUMN price INTEGER NOT NULL DEFAULT 0 CHECK (price >= 0);
+ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE order_items ADD COLUMN unit_price INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
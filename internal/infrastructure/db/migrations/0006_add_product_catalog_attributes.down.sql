@@ -0,0 +1,6 @@
+DROP INDEX idx_products_sku;
+
+ALTER TABLE products DROP COLUMN barcode;
+ALTER TABLE products DROP COLUMN category;
+ALTER TABLE products DROP COLUMN description;
+ALTER TABLE products DROP COLUMN sku;
@@ -0,0 +1,8 @@
+-- SKUs identify products in the warehouse. They are optional so existing
+-- products remain valid, but unique (ignoring case) when present.
+ALTER TABLE products ADD COLUMN sku TEXT;
+ALTER TABLE products ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN category TEXT NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN barcode TEXT;
+
+CREATE UNIQUE INDEX idx_products_sku ON products(sku COLLATE NOCASE);
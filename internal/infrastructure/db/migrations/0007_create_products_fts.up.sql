@@ -0,0 +1,35 @@
+-- migrate:requires ENABLE_FTS5
+
+-- Full-text index over the searchable product fields. It is an external
+-- content table: the text lives in products and the triggers below keep the
+-- index in step with every insert, update and delete.
+CREATE VIRTUAL TABLE products_fts USING fts5(
+	name,
+	sku,
+	category,
+	description,
+	content = 'products',
+	content_rowid = 'id',
+	tokenize = 'unicode61 remove_diacritics 2',
+	prefix = '2 3'
+);
+
+CREATE TRIGGER products_fts_insert AFTER INSERT ON products BEGIN
+	INSERT INTO products_fts (rowid, name, sku, category, description)
+	VALUES (new.id, new.name, new.sku, new.category, new.description);
+END;
+
+CREATE TRIGGER products_fts_delete AFTER DELETE ON products BEGIN
+	INSERT INTO products_fts (products_fts, rowid, name, sku, category, description)
+	VALUES ('delete', old.id, old.name, old.sku, old.category, old.description);
+END;
+
+-- Stock changes do not touch the indexed columns and so skip the index
+CREATE TRIGGER products_fts_update AFTER UPDATE OF name, sku, category, description ON products BEGIN
+	INSERT INTO products_fts (products_fts, rowid, name, sku, category, description)
+	VALUES ('delete', old.id, old.name, old.sku, old.category, old.description);
+	INSERT INTO products_fts (rowid, name, sku, category, description)
+	VALUES (new.id, new.name, new.sku, new.category, new.description);
+END;
+
+INSERT INTO products_fts (products_fts) VALUES ('rebuild');
@@ -0,0 +1,30 @@
+-- Append-only ledger of every stock change. balance is the product's stock
+-- after the change, so the latest row for a product must equal
+-- products.stock and the deltas must sum to it. product_id is deliberately
+-- not a foreign key: the ledger outlives deleted products.
+CREATE TABLE stock_movements (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	delta INTEGER NOT NULL CHECK (delta <> 0),
+	balance INTEGER NOT NULL CHECK (balance >= 0),
+	reason TEXT NOT NULL,
+	reference_id TEXT,
+	actor TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_stock_movements_product_id ON stock_movements(product_id, id);
+
+CREATE TRIGGER stock_movements_no_update BEFORE UPDATE ON stock_movements BEGIN
+	SELECT RAISE(ABORT, 'stock_movements is append-only');
+END;
+
+CREATE TRIGGER stock_movements_no_delete BEFORE DELETE ON stock_movements BEGIN
+	SELECT RAISE(ABORT, 'stock_movements is append-only');
+END;
+
+-- Existing stock has no history; record it as an opening balance
+INSERT INTO stock_movements (product_id, delta, balance, reason, reference_id, actor, created_at)
+	SELECT id, stock, stock, 'opening_balance', NULL, 'system', CURRENT_TIMESTAMP
+	FROM products
+	WHERE stock <> 0;
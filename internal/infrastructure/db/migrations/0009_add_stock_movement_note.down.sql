@@ -0,0 +1 @@
+ALTER TABLE stock_movements DROP COLUMN note;
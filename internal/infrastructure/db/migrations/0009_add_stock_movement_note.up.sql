@@ -0,0 +1,2 @@
+-- Free-text explanation supplied with restocks and manual adjustments
+ALTER TABLE stock_movements ADD COLUMN note TEXT;
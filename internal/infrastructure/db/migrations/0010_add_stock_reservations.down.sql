@@ -0,0 +1,14 @@
+-- Outstanding holds become stock consumed by their orders, as before
+-- reservations existed, and are recorded in the ledger accordingly.
+INSERT INTO stock_movements (product_id, delta, balance, reason, note, actor, created_at)
+	SELECT id, -reserved, stock - reserved, 'order', 'reservation converted on downgrade', 'system', CURRENT_TIMESTAMP
+	FROM products
+	WHERE reserved > 0;
+
+UPDATE products SET stock = stock - reserved WHERE reserved > 0;
+
+DROP INDEX IF EXISTS idx_orders_status_reserved_until;
+
+ALTER TABLE orders DROP COLUMN reserved_until;
+
+ALTER TABLE products DROP COLUMN reserved;
@@ -0,0 +1,9 @@
+-- Pending orders hold stock instead of consuming it. reserved is the quantity
+-- held for pending orders; the hold on an order lapses at reserved_until.
+-- Orders placed before reservations existed keep a NULL reserved_until and
+-- already consumed their stock.
+ALTER TABLE products ADD COLUMN reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+
+ALTER TABLE orders ADD COLUMN reserved_until DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_orders_status_reserved_until ON orders(status, reserved_until);
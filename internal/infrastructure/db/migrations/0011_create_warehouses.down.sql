@@ -0,0 +1,19 @@
+-- Stock collapses back to the per-product totals kept in products. Ledger
+-- rows written since the upgrade keep their per-warehouse balances.
+DROP INDEX IF EXISTS idx_stock_transfers_product_id;
+
+DROP TABLE IF EXISTS stock_transfers;
+
+DROP INDEX IF EXISTS idx_stock_movements_product_warehouse;
+
+ALTER TABLE stock_movements DROP COLUMN warehouse_id;
+
+ALTER TABLE order_items DROP COLUMN warehouse_id;
+
+DROP INDEX IF EXISTS idx_warehouse_stock_product_id;
+
+DROP TABLE IF EXISTS warehouse_stock;
+
+DROP INDEX IF EXISTS idx_warehouses_code;
+
+DROP TABLE IF EXISTS warehouses;
@@ -0,0 +1,67 @@
+-- Stock is held per warehouse. products.stock and products.reserved remain as
+-- the totals across warehouses and are kept in step with warehouse_stock.
+CREATE TABLE warehouses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code TEXT NOT NULL,
+	name TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_warehouses_code ON warehouses(code COLLATE NOCASE);
+
+-- Existing stock moves to the primary warehouse, the one with the lowest id
+INSERT INTO warehouses (code, name, created_at, updated_at)
+	VALUES ('MAIN', 'Main warehouse', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+CREATE TABLE warehouse_stock (
+	warehouse_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+	reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+	PRIMARY KEY (warehouse_id, product_id),
+	FOREIGN KEY (warehouse_id) REFERENCES warehouses (id),
+	FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+CREATE INDEX idx_warehouse_stock_product_id ON warehouse_stock(product_id);
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, stock, reserved)
+	SELECT (SELECT MIN(id) FROM warehouses), id, stock, reserved
+	FROM products;
+
+-- Order lines record the warehouse they are fulfilled from
+ALTER TABLE order_items ADD COLUMN warehouse_id INTEGER;
+
+UPDATE order_items SET warehouse_id = (SELECT MIN(id) FROM warehouses);
+
+-- Ledger rows record the warehouse whose stock changed; from here on balance
+-- is that warehouse's stock of the product. Earlier rows all belong to the
+-- primary warehouse, which held all stock, so their balances still hold.
+ALTER TABLE stock_movements ADD COLUMN warehouse_id INTEGER;
+
+DROP TRIGGER stock_movements_no_update;
+
+UPDATE stock_movements SET warehouse_id = (SELECT MIN(id) FROM warehouses);
+
+CREATE TRIGGER stock_movements_no_update BEFORE UPDATE ON stock_movements BEGIN
+	SELECT RAISE(ABORT, 'stock_movements is append-only');
+END;
+
+CREATE INDEX idx_stock_movements_product_warehouse ON stock_movements(product_id, warehouse_id, id);
+
+-- A transfer moves stock between two warehouses; it is also recorded as a
+-- pair of ledger rows referencing it
+CREATE TABLE stock_transfers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	from_warehouse_id INTEGER NOT NULL,
+	to_warehouse_id INTEGER NOT NULL,
+	quantity INTEGER NOT NULL CHECK (quantity > 0),
+	note TEXT,
+	actor TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	CHECK (from_warehouse_id <> to_warehouse_id)
+);
+
+CREATE INDEX idx_stock_transfers_product_id ON stock_transfers(product_id, id);
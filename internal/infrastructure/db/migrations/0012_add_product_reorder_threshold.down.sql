@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_products_reorder_threshold;
+
+ALTER TABLE products DROP COLUMN reorder_threshold;
@@ -0,0 +1,6 @@
+-- Per-product reorder point. A product is low on stock once its available
+-- quantity (stock - reserved) is at or below reorder_threshold; NULL means
+-- the product is not monitored.
+ALTER TABLE products ADD COLUMN reorder_threshold INTEGER CHECK (reorder_threshold IS NULL OR reorder_threshold >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_products_reorder_threshold ON products(reorder_threshold) WHERE reorder_threshold IS NOT NULL;
@@ -0,0 +1,10 @@
+-- Backorders hold no stock, so reverting only needs to cancel them
+UPDATE orders SET status = 'cancelled' WHERE status = 'backordered';
+
+UPDATE order_items SET warehouse_id = (SELECT MIN(id) FROM warehouses) WHERE warehouse_id IS NULL;
+
+DROP INDEX IF EXISTS idx_orders_status;
+
+ALTER TABLE products DROP COLUMN max_backorder_depth;
+
+ALTER TABLE products DROP COLUMN allow_backorder;
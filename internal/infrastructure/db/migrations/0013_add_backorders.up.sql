@@ -0,0 +1,10 @@
+-- Products may accept orders they cannot fill yet. Such orders are queued as
+-- backordered, first in first out per product, and hold no stock until a
+-- restock lets them be allocated. max_backorder_depth caps how many orders
+-- may be queued for the product; NULL means no limit.
+ALTER TABLE products ADD COLUMN allow_backorder INTEGER NOT NULL DEFAULT 0 CHECK (allow_backorder IN (0, 1));
+ALTER TABLE products ADD COLUMN max_backorder_depth INTEGER CHECK (max_backorder_depth IS NULL OR max_backorder_depth > 0);
+
+-- Serves the per-product backorder queue, read in order ID order. Lines of
+-- a backordered order keep a NULL warehouse_id until the order is filled.
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status, id);
@@ -0,0 +1,9 @@
+ALTER TABLE products DROP COLUMN max_per_user_window_seconds;
+
+ALTER TABLE products DROP COLUMN max_per_user;
+
+ALTER TABLE products DROP COLUMN order_quantity_step;
+
+ALTER TABLE products DROP COLUMN max_order_quantity;
+
+ALTER TABLE products DROP COLUMN min_order_quantity;
@@ -0,0 +1,10 @@
+-- Per-product order quantity rules; NULL means unrestricted. Quantities on one
+-- order must lie between min_order_quantity and max_order_quantity and be a
+-- multiple of order_quantity_step. A user may order at most max_per_user in
+-- any max_per_user_window_seconds, counted from their orders that were not
+-- cancelled.
+ALTER TABLE products ADD COLUMN min_order_quantity INTEGER CHECK (min_order_quantity IS NULL OR min_order_quantity > 0);
+ALTER TABLE products ADD COLUMN max_order_quantity INTEGER CHECK (max_order_quantity IS NULL OR max_order_quantity > 0);
+ALTER TABLE products ADD COLUMN order_quantity_step INTEGER CHECK (order_quantity_step IS NULL OR order_quantity_step > 0);
+ALTER TABLE products ADD COLUMN max_per_user INTEGER CHECK (max_per_user IS NULL OR max_per_user > 0);
+ALTER TABLE products ADD COLUMN max_per_user_window_seconds INTEGER CHECK (max_per_user_window_seconds IS NULL OR max_per_user_window_seconds > 0);
@@ -0,0 +1,14 @@
+-- Static API keys. Only the SHA-256 hash of a key is stored; prefix keeps its
+-- first characters so a key can be recognised in listings. scopes is a space
+-- separated list.
+CREATE TABLE api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	scopes TEXT NOT NULL DEFAULT '',
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME,
+	revoked_at DATETIME
+);
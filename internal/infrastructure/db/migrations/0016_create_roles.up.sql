@@ -0,0 +1,60 @@
+-- Role-based access control. Roles grant permissions and are assigned to
+-- subjects, the users credentials are issued to. A subject without an
+-- assigned role is a customer.
+CREATE TABLE roles (
+	name TEXT PRIMARY KEY,
+	description TEXT NOT NULL
+);
+
+CREATE TABLE role_permissions (
+	role TEXT NOT NULL,
+	permission TEXT NOT NULL,
+	PRIMARY KEY (role, permission),
+	FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE
+);
+
+CREATE TABLE user_roles (
+	subject TEXT NOT NULL,
+	role TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (subject, role),
+	FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_roles_role ON user_roles(role);
+
+INSERT INTO roles (name, description) VALUES
+	('customer', 'Browses the catalogue and places, reads and cancels their own orders'),
+	('staff', 'Manages products, stock and every order'),
+	('admin', 'Everything staff may do, and manages warehouses and roles');
+
+INSERT INTO role_permissions (role, permission) VALUES
+	('customer', 'products:read'),
+	('customer', 'warehouses:read'),
+	('customer', 'orders:create'),
+	('customer', 'orders:read:own'),
+	('customer', 'orders:cancel:own'),
+
+	('staff', 'products:read'),
+	('staff', 'products:write'),
+	('staff', 'stock:read'),
+	('staff', 'stock:write'),
+	('staff', 'warehouses:read'),
+	('staff', 'orders:create'),
+	('staff', 'orders:read:own'),
+	('staff', 'orders:read:all'),
+	('staff', 'orders:cancel:own'),
+	('staff', 'orders:manage'),
+
+	('admin', 'products:read'),
+	('admin', 'products:write'),
+	('admin', 'stock:read'),
+	('admin', 'stock:write'),
+	('admin', 'warehouses:read'),
+	('admin', 'warehouses:write'),
+	('admin', 'orders:create'),
+	('admin', 'orders:read:own'),
+	('admin', 'orders:read:all'),
+	('admin', 'orders:cancel:own'),
+	('admin', 'orders:manage'),
+	('admin', 'roles:manage');
@@ -0,0 +1,410 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// requiresPattern matches a "-- migrate:requires OPTION" line in an up script.
+// OPTION is a SQLite compile-time option such as ENABLE_FTS5.
+var requiresPattern = regexp.MustCompile(`(?m)^--\s*migrate:requires\s+(\w+)\s*$`)
+
+const (
+	lockPollInterval = 200 * time.Millisecond
+	// How long a migrator waits for another to release the lock by default
+	defaultLockTimeout = 30 * time.Second
+	// A lock older than this is assumed to belong to a crashed process
+	staleLockAge = 10 * time.Minute
+)
+
+// Migration is one numbered schema change with its up and down scripts.
+// Requires lists the SQLite compile-time options the up script needs.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+	Requires []string
+}
+
+// MigrationStatus reports whether a migration has been applied and whether
+// its script has changed since. Missing lists required SQLite options the
+// current build lacks.
+type MigrationStatus struct {
+	Migration
+	Applied          bool
+	AppliedAt        time.Time
+	ChecksumMismatch bool
+	Missing          []string
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies the embedded migrations and records them in
+// schema_migrations. A row in schema_migrations_lock serialises concurrent
+// migrators across processes.
+type Migrator struct {
+	db         *sql.DB
+	logger     logger.Logger
+	migrations []Migration
+	owner      string
+	// lockTimeout is how long Up and Down wait for the migration lock
+	lockTimeout time.Duration
+}
+
+func NewMigrator(db *sql.DB, logger logger.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Migrator{
+		db:          db,
+		logger:      logger,
+		migrations:  migrations,
+		owner:       fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		lockTimeout: defaultLockTimeout,
+	}, nil
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(files, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+			for _, req := range requiresPattern.FindAllStringSubmatch(m.Up, -1) {
+				m.Requires = append(m.Requires, req[1])
+			}
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) needs both up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order and returns how many
+// were applied. It refuses to run if an applied migration has been edited.
+// It stops with an error at the first migration that needs a SQLite option
+// this build lacks, whether pending or already applied, leaving it and every
+// later migration unapplied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			record, ok := applied[migration.Version]
+			if ok {
+				if record.checksum != migration.Checksum {
+					return fmt.Errorf("migration %d (%s) has changed since it was applied", migration.Version, migration.Name)
+				}
+				// Objects created by the migration, such as triggers, would fail at runtime
+				missing, err := m.missingOptions(ctx, migration.Requires)
+				if err != nil {
+					return err
+				}
+				if len(missing) > 0 {
+					return fmt.Errorf("migration %d (%s) is applied but SQLite was built without %s", migration.Version, migration.Name, strings.Join(missing, ", "))
+				}
+				continue
+			}
+
+			missing, err := m.missingOptions(ctx, migration.Requires)
+			if err != nil {
+				return err
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("migration %d (%s) requires SQLite built with %s", migration.Version, migration.Name, strings.Join(missing, ", "))
+			}
+
+			m.logger.Info("Applying migration", "version", migration.Version, "name", migration.Name)
+
+			err = m.inTx(ctx, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+					migration.Version, migration.Name, migration.Checksum, time.Now())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down reverts the most recently applied migrations, at most steps of them,
+// and returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			m.logger.Info("Reverting migration", "version", migration.Version, "name", migration.Name)
+
+			err := m.inTx(ctx, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status lists every known migration with its applied state.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.appliedAt
+			status.ChecksumMismatch = record.checksum != migration.Checksum
+		} else if status.Missing, err = m.missingOptions(ctx, migration.Requires); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// missingOptions returns the options in required that SQLite was not
+// compiled with.
+func (m *Migrator) missingOptions(ctx context.Context, required []string) ([]string, error) {
+	var missing []string
+	for _, option := range required {
+		var used bool
+		if err := m.db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used(?)`, option).Scan(&used); err != nil {
+			return nil, fmt.Errorf("failed to check SQLite option %s: %w", option, err)
+		}
+		if !used {
+			missing = append(missing, option)
+		}
+	}
+	return missing, nil
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			owner TEXT NOT NULL,
+			locked_at DATETIME NOT NULL
+		)`,
+	}
+
+	for _, query := range queries {
+		if _, err := m.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create migration tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var (
+			version int
+			record  appliedMigration
+		)
+		if err := rows.Scan(&version, &record.checksum, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = record
+	}
+
+	return applied, rows.Err()
+}
+
+// withLock runs fn while holding the migration lock, waiting up to
+// m.lockTimeout for another migrator to finish.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		acquired, err := m.tryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for migration lock")
+		}
+
+		m.logger.Info("Waiting for migration lock held by another process")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	defer func() {
+		if _, err := m.db.ExecContext(context.Background(),
+			`DELETE FROM schema_migrations_lock WHERE id = 1 AND owner = ?`, m.owner); err != nil {
+			m.logger.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	return fn()
+}
+
+func (m *Migrator) tryLock(ctx context.Context) (bool, error) {
+	now := time.Now()
+
+	if _, err := m.db.ExecContext(ctx,
+		`DELETE FROM schema_migrations_lock WHERE id = 1 AND locked_at < ?`, now.Add(-staleLockAge)); err != nil {
+		return false, fmt.Errorf("failed to clear stale migration lock: %w", err)
+	}
+
+	result, err := m.db.ExecContext(ctx,
+		`INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, ?, ?) ON CONFLICT (id) DO NOTHING`,
+		m.owner, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0001_create_widgets.up.sql":   {Data: []byte(`CREATE TABLE widgets (id INTEGER PRIMARY KEY);`)},
+	"migrations/0001_create_widgets.down.sql": {Data: []byte(`DROP TABLE widgets;`)},
+	"migrations/0002_create_gadgets.up.sql":   {Data: []byte(`CREATE TABLE gadgets (id INTEGER PRIMARY KEY);`)},
+	"migrations/0002_create_gadgets.down.sql": {Data: []byte(`DROP TABLE gadgets;`)},
+}
+
+// newTestMigrator returns a migrator for files on a fresh database, with a
+// short lock timeout.
+func newTestMigrator(t *testing.T, files fstest.MapFS) *Migrator {
+	t.Helper()
+
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"), logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	return migrator.withMigrations(t, files)
+}
+
+// withMigrations returns a copy of m that applies files instead of the
+// embedded migrations.
+func (m *Migrator) withMigrations(t *testing.T, files fstest.MapFS) *Migrator {
+	t.Helper()
+
+	migrations, err := loadMigrations(files)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	copied := *m
+	copied.migrations = migrations
+	copied.lockTimeout = 500 * time.Millisecond
+	return &copied
+}
+
+func tableExists(t *testing.T, m *Migrator, name string) bool {
+	t.Helper()
+	var count int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatalf("failed to look up table %s: %v", name, err)
+	}
+	return count == 1
+}
+
+func TestUpAndDownRoundTrip(t *testing.T) {
+	m := newTestMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	if applied, err := m.Up(ctx); err != nil || applied != 2 {
+		t.Fatalf("Up = %d, %v; want 2, nil", applied, err)
+	}
+	if !tableExists(t, m, "widgets") || !tableExists(t, m, "gadgets") {
+		t.Fatal("Up did not create the tables")
+	}
+	if applied, err := m.Up(ctx); err != nil || applied != 0 {
+		t.Fatalf("second Up = %d, %v; want 0, nil", applied, err)
+	}
+
+	if reverted, err := m.Down(ctx, 1); err != nil || reverted != 1 {
+		t.Fatalf("Down(1) = %d, %v; want 1, nil", reverted, err)
+	}
+	if !tableExists(t, m, "widgets") || tableExists(t, m, "gadgets") {
+		t.Fatal("Down(1) did not revert only the latest migration")
+	}
+
+	if reverted, err := m.Down(ctx, 5); err != nil || reverted != 1 {
+		t.Fatalf("Down(5) = %d, %v; want 1, nil", reverted, err)
+	}
+	if tableExists(t, m, "widgets") {
+		t.Fatal("Down did not revert the first migration")
+	}
+
+	if applied, err := m.Up(ctx); err != nil || applied != 2 {
+		t.Fatalf("Up after Down = %d, %v; want 2, nil", applied, err)
+	}
+}
+
+func TestUpRejectsEditedMigration(t *testing.T) {
+	m := newTestMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	edited := fstest.MapFS{}
+	for name, file := range testMigrations {
+		edited[name] = file
+	}
+	edited["migrations/0001_create_widgets.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT);`)}
+
+	_, err := m.withMigrations(t, edited).Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "migration 1 (create_widgets) has changed") {
+		t.Fatalf("Up with an edited migration = %v, want a changed migration error", err)
+	}
+
+	statuses, err := m.withMigrations(t, edited).Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !statuses[0].ChecksumMismatch || statuses[1].ChecksumMismatch {
+		t.Errorf("Status checksum mismatches = %v, %v; want true, false", statuses[0].ChecksumMismatch, statuses[1].ChecksumMismatch)
+	}
+}
+
+func TestUpStopsAtMigrationWithMissingOption(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0003_needs_option.up.sql":   {Data: []byte("-- migrate:requires NOT_A_REAL_OPTION\nCREATE TABLE needs_option (id INTEGER PRIMARY KEY);")},
+		"migrations/0003_needs_option.down.sql": {Data: []byte(`DROP TABLE needs_option;`)},
+		"migrations/0004_create_later.up.sql":   {Data: []byte(`CREATE TABLE later (id INTEGER PRIMARY KEY);`)},
+		"migrations/0004_create_later.down.sql": {Data: []byte(`DROP TABLE later;`)},
+	}
+	for name, file := range testMigrations {
+		files[name] = file
+	}
+	m := newTestMigrator(t, files)
+
+	applied, err := m.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "NOT_A_REAL_OPTION") {
+		t.Fatalf("Up = %v, want an error naming the missing option", err)
+	}
+	if applied != 2 {
+		t.Errorf("Up applied %d migrations, want the 2 before the unappliable one", applied)
+	}
+	if tableExists(t, m, "later") {
+		t.Error("Up applied a migration after the unappliable one")
+	}
+}
+
+func TestUpWaitsForMigrationLock(t *testing.T) {
+	m := newTestMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	if err := m.ensureTables(ctx); err != nil {
+		t.Fatalf("failed to create migration tables: %v", err)
+	}
+	if _, err := m.db.Exec(`INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, 'other', ?)`, time.Now()); err != nil {
+		t.Fatalf("failed to take the lock: %v", err)
+	}
+
+	// A lock held by a live migrator is waited on, then given up
+	_, err := m.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "timed out waiting for migration lock") {
+		t.Fatalf("Up while locked = %v, want a lock timeout", err)
+	}
+	if tableExists(t, m, "widgets") {
+		t.Fatal("Up applied migrations without the lock")
+	}
+
+	// A lock left behind by a crashed migrator is taken over
+	if _, err := m.db.Exec(`UPDATE schema_migrations_lock SET locked_at = ?`, time.Now().Add(-staleLockAge-time.Minute)); err != nil {
+		t.Fatalf("failed to age the lock: %v", err)
+	}
+	if applied, err := m.Up(ctx); err != nil || applied != 2 {
+		t.Fatalf("Up with a stale lock = %d, %v; want 2, nil", applied, err)
+	}
+
+	var locks int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations_lock`).Scan(&locks); err != nil {
+		t.Fatalf("failed to count locks: %v", err)
+	}
+	if locks != 0 {
+		t.Errorf("Up left %d locks behind", locks)
+	}
+}
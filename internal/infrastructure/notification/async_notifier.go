@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"context"
+	"sync"
+
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+// AsyncNotifier delivers alerts through another notifier in the background,
+// so a slow webhook does not hold up the stock change that raised them.
+// Deliveries are tracked, and Drain waits for the ones still in flight.
+type AsyncNotifier struct {
+	next   output.StockAlertNotifier
+	logger logger.Logger
+	wg     sync.WaitGroup
+}
+
+// NewAsyncNotifier returns a notifier that hands each alert to next on its
+// own goroutine.
+func NewAsyncNotifier(next output.StockAlertNotifier, logger logger.Logger) *AsyncNotifier {
+	return &AsyncNotifier{next: next, logger: logger}
+}
+
+// NotifyLowStock implements output.StockAlertNotifier. It returns at once;
+// the delivery outlives ctx, whose caller may be gone by the time it runs,
+// and a failure is logged rather than returned.
+func (n *AsyncNotifier) NotifyLowStock(ctx context.Context, alert *entity.LowStockAlert) error {
+	ctx = context.WithoutCancel(ctx)
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.next.NotifyLowStock(ctx, alert); err != nil {
+			n.logger.Error("Failed to send low-stock alert", "product_id", alert.ProductID, "error", err)
+		}
+	}()
+	return nil
+}
+
+// Drain waits until every delivery started so far has finished, or until ctx
+// is done, in which case the remaining deliveries are abandoned and ctx's
+// error is returned.
+func (n *AsyncNotifier) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
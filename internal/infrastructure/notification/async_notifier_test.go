@@ -0,0 +1,45 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+// blockingNotifier holds each alert until release is closed.
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (n *blockingNotifier) NotifyLowStock(ctx context.Context, alert *entity.LowStockAlert) error {
+	<-n.release
+	return nil
+}
+
+func TestDrainWaitsForDeliveriesInFlight(t *testing.T) {
+	next := &blockingNotifier{release: make(chan struct{})}
+	notifier := NewAsyncNotifier(next, logger.NewNopLogger())
+
+	if err := notifier.NotifyLowStock(context.Background(), &entity.LowStockAlert{ProductID: 1}); err != nil {
+		t.Fatalf("NotifyLowStock returned %v", err)
+	}
+
+	// A delivery still in flight when the deadline passes is reported
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := notifier.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain with a blocked delivery = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(next.release)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Drain(ctx); err != nil {
+		t.Fatalf("Drain after delivery = %v, want nil", err)
+	}
+}
@@ -0,0 +1,31 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+type logNotifier struct {
+	logger logger.Logger
+}
+
+// NewLogNotifier returns a notifier that writes each alert to the log at warn
+// level. It is the default when no webhook is configured.
+func NewLogNotifier(logger logger.Logger) output.StockAlertNotifier {
+	return &logNotifier{logger: logger}
+}
+
+// NotifyLowStock implements output.StockAlertNotifier.
+func (n *logNotifier) NotifyLowStock(ctx context.Context, alert *entity.LowStockAlert) error {
+	n.logger.Warn("Product stock is low",
+		"event", alert.Event,
+		"product_id", alert.ProductID,
+		"sku", alert.SKU,
+		"available", alert.Available,
+		"previous_available", alert.PreviousAvailable,
+		"reorder_threshold", alert.ReorderThreshold)
+	return nil
+}
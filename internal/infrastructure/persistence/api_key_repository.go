@@ -0,0 +1,143 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+)
+
+const apiKeyColumns = `id, name, subject, scopes, prefix, key_hash, created_at, expires_at, revoked_at`
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) repository.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var (
+		key       entity.APIKey
+		scopes    string
+		expiresAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Subject,
+		&scopes,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.CreatedAt,
+		&expiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
+
+// Create implements repository.APIKeyRepository.
+func (a *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, subject, scopes, prefix, key_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := getExecutor(ctx, a.db).ExecContext(ctx, query,
+		key.Name,
+		key.Subject,
+		strings.Join(key.Scopes, " "),
+		key.Prefix,
+		key.KeyHash,
+		key.CreatedAt,
+		key.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	key.ID = int(id)
+	return nil
+}
+
+// GetByHash implements repository.APIKeyRepository.
+func (a *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+
+	key, err := scanAPIKey(getExecutor(ctx, a.db).QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domainerr.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetAll implements repository.APIKeyRepository.
+func (a *apiKeyRepository) GetAll(ctx context.Context) ([]*entity.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	rows, err := getExecutor(ctx, a.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*entity.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke implements repository.APIKeyRepository.
+func (a *apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`
+
+	result, err := getExecutor(ctx, a.db).ExecContext(ctx, query, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domainerr.ErrAPIKeyNotFound.Withf("API key with id %d not found", id)
+	}
+
+	return nil
+}
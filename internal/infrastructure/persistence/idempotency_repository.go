@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+)
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) repository.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve implements repository.IdempotencyRepository.
+func (i *idempotencyRepository) Reserve(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Expired keys are free to be reused
+	if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to purge expired idempotency keys: %w", err)
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (key, scope, request_hash, created_at, expires_at) 
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (scope, key) DO NOTHING
+	`
+	result, err := tx.ExecContext(ctx, query,
+		record.Key,
+		record.Scope,
+		record.RequestHash,
+		record.CreatedAt,
+		record.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	var existing *entity.IdempotencyRecord
+	if rowsAffected == 0 {
+		existing, err = i.get(ctx, tx, record.Scope, record.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return existing, nil
+}
+
+func (i *idempotencyRepository) get(ctx context.Context, exec executor, scope string, key string) (*entity.IdempotencyRecord, error) {
+	query := `
+		SELECT key, scope, request_hash, status_code, content_type, response_body, created_at, expires_at 
+		FROM idempotency_keys 
+		WHERE scope = ? AND key = ?
+	`
+
+	var (
+		record      entity.IdempotencyRecord
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+	)
+	err := exec.QueryRowContext(ctx, query, scope, key).Scan(
+		&record.Key,
+		&record.Scope,
+		&record.RequestHash,
+		&statusCode,
+		&contentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+	return &record, nil
+}
+
+// Complete implements repository.IdempotencyRepository.
+func (i *idempotencyRepository) Complete(ctx context.Context, scope string, key string, statusCode int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys 
+		SET status_code = ?, content_type = ?, response_body = ? 
+		WHERE scope = ? AND key = ?
+	`
+
+	if _, err := getExecutor(ctx, i.db).ExecContext(ctx, query, statusCode, contentType, body, scope, key); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release implements repository.IdempotencyRepository.
+func (i *idempotencyRepository) Release(ctx context.Context, scope string, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = ? AND key = ? AND status_code IS NULL`
+
+	if _, err := getExecutor(ctx, i.db).ExecContext(ctx, query, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
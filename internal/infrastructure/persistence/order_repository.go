@@ -4,29 +4,134 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
 	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 	"github.com/WaveCE29/product_order_system/internal/domain/repository"
 )
 
+const orderColumns = `id, user_id, status, idempotency_key, request_fingerprint, created_at, reserved_until`
+
+const orderItemColumns = `id, order_id, product_id, warehouse_id, quantity, unit_price, currency`
+
+var orderSortColumns = map[string]sortColumn{
+	"id":         {column: "id", kind: kindInt},
+	"created_at": {column: "created_at", kind: kindTime},
+}
+
 type orderRepository struct {
 	db *sql.DB
 }
 
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row rowScanner) (*entity.Order, error) {
+	var (
+		order          entity.Order
+		idempotencyKey sql.NullString
+		fingerprint    sql.NullString
+		reservedUntil  sql.NullTime
+	)
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.Status,
+		&idempotencyKey,
+		&fingerprint,
+		&order.CreatedAt,
+		&reservedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	order.IdempotencyKey = idempotencyKey.String
+	order.RequestFingerprint = fingerprint.String
+	if reservedUntil.Valid {
+		order.ReservedUntil = &reservedUntil.Time
+	}
+	return &order, nil
+}
+
+func scanOrderItem(row rowScanner) (*entity.OrderItem, error) {
+	var (
+		item        entity.OrderItem
+		warehouseID sql.NullInt64
+	)
+	if err := row.Scan(&item.ID, &item.OrderID, &item.ProductID, &warehouseID, &item.Quantity, &item.UnitPrice, &item.Currency); err != nil {
+		return nil, err
+	}
+	item.WarehouseID = int(warehouseID.Int64)
+	return &item, nil
+}
+
+// loadItems attaches the lines of every given order with a single query.
+func (o *orderRepository) loadItems(ctx context.Context, orders ...*entity.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*entity.Order, len(orders))
+	placeholders := make([]string, 0, len(orders))
+	args := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		order.Items = []*entity.OrderItem{}
+		byID[order.ID] = order
+		placeholders = append(placeholders, "?")
+		args = append(args, order.ID)
+	}
+
+	query := `SELECT ` + orderItemColumns + ` FROM order_items WHERE order_id IN (` +
+		strings.Join(placeholders, ", ") + `) ORDER BY order_id, id`
+
+	rows, err := getExecutor(ctx, o.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanOrderItem(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+		if order, ok := byID[item.OrderID]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating order items: %w", err)
+	}
+
+	for _, order := range orders {
+		order.CalculateTotals()
+	}
+
+	return nil
+}
+
 // Create implements repository.OrderRepository.
+// The header and its items are written together; callers run it inside a
+// transaction so a failed item insert does not leave a partial order.
 func (o *orderRepository) Create(ctx context.Context, order *entity.Order) error {
 	query := `
-		INSERT INTO orders (product_id, user_id, quantity, status, idempotency_key, created_at) 
+		INSERT INTO orders (user_id, status, idempotency_key, request_fingerprint, created_at, reserved_until) 
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := o.db.ExecContext(ctx, query,
-		order.ProductID,
+	exec := getExecutor(ctx, o.db)
+	result, err := exec.ExecContext(ctx, query,
 		order.UserID,
-		order.Quantity,
 		order.Status,
-		order.IdempotencyKey,
-		order.CreatedAt)
+		nullString(order.IdempotencyKey),
+		order.RequestFingerprint,
+		order.CreatedAt,
+		order.ReservedUntil)
 	if err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
@@ -37,18 +142,31 @@ func (o *orderRepository) Create(ctx context.Context, order *entity.Order) error
 	}
 
 	order.ID = int(id)
+
+	itemQuery := `INSERT INTO order_items (order_id, product_id, warehouse_id, quantity, unit_price, currency) VALUES (?, ?, ?, ?, ?, ?)`
+	for _, item := range order.Items {
+		result, err := exec.ExecContext(ctx, itemQuery, order.ID, item.ProductID, nullID(item.WarehouseID), item.Quantity, item.UnitPrice, item.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to create order item: %w", err)
+		}
+
+		itemID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		item.ID = int(itemID)
+		item.OrderID = order.ID
+	}
+
 	return nil
 }
 
 // GetAll implements repository.OrderRepository.
 func (o *orderRepository) GetAll(ctx context.Context) ([]*entity.Order, error) {
-	query := `
-		SELECT id, product_id, user_id, quantity, status, idempotency_key, created_at 
-		FROM orders 
-		ORDER BY created_at DESC
-	`
+	query := `SELECT ` + orderColumns + ` FROM orders ORDER BY created_at DESC`
 
-	rows, err := o.db.QueryContext(ctx, query)
+	rows, err := getExecutor(ctx, o.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders: %w", err)
 	}
@@ -56,80 +174,319 @@ func (o *orderRepository) GetAll(ctx context.Context) ([]*entity.Order, error) {
 
 	var orders []*entity.Order
 	for rows.Next() {
-		var order entity.Order
-		err := rows.Scan(
-			&order.ID,
-			&order.ProductID,
-			&order.UserID,
-			&order.Quantity,
-			&order.Status,
-			&order.IdempotencyKey,
-			&order.CreatedAt,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
-		orders = append(orders, &order)
+		orders = append(orders, order)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating orders: %w", err)
 	}
+	rows.Close()
+
+	if err := o.loadItems(ctx, orders...); err != nil {
+		return nil, err
+	}
 
 	return orders, nil
 }
 
+// List implements repository.OrderRepository.
+func (o *orderRepository) List(ctx context.Context, filter repository.OrderFilter, page repository.PageRequest) ([]*entity.Order, string, error) {
+	ks, err := newKeyset(page.Sort, "-created_at", orderSortColumns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	// user_id and product_id are served by idx_orders_user_id and idx_order_items_product_id
+	if filter.UserID != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.ProductID > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.product_id = ?)")
+		args = append(args, filter.ProductID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.CreatedFrom)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+	if page.Cursor != "" {
+		clause, seekArgs, err := ks.seek(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, clause)
+		args = append(args, seekArgs...)
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders `
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ") + " "
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	limit := page.PageLimit()
+	query += "ORDER BY " + ks.orderBy() + " LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := getExecutor(ctx, o.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*entity.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating orders: %w", err)
+	}
+	rows.Close()
+
+	var nextCursor string
+	if len(orders) > limit {
+		orders = orders[:limit]
+		last := orders[limit-1]
+		nextCursor, err = ks.encodeCursor(orderSortValue(last, ks.column.column), last.ID)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := o.loadItems(ctx, orders...); err != nil {
+		return nil, "", err
+	}
+
+	return orders, nextCursor, nil
+}
+
+func orderSortValue(order *entity.Order, column string) interface{} {
+	switch column {
+	case "created_at":
+		return order.CreatedAt
+	default:
+		return order.ID
+	}
+}
+
 // GetByID implements repository.OrderRepository.
 func (o *orderRepository) GetByID(ctx context.Context, id int) (*entity.Order, error) {
-	query := `
-		SELECT id, product_id, user_id, quantity, status, idempotency_key, created_at 
-		FROM orders 
-		WHERE id = ?
-	`
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = ?`
 
-	var order entity.Order
-	err := o.db.QueryRowContext(ctx, query, id).Scan(
-		&order.ID,
-		&order.ProductID,
-		&order.UserID,
-		&order.Quantity,
-		&order.Status,
-		&order.IdempotencyKey,
-		&order.CreatedAt,
-	)
+	order, err := scanOrder(getExecutor(ctx, o.db).QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("order with id %d not found", id)
+			return nil, domainerr.ErrOrderNotFound.Withf("order with id %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	return &order, nil
+	if err := o.loadItems(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
 }
 
 // GetByIdempotencyKey implements repository.OrderRepository.
-func (o *orderRepository) GetByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error) {
+// Keys are scoped per user, so two users may independently use the same key.
+func (o *orderRepository) GetByIdempotencyKey(ctx context.Context, userID string, key string) (*entity.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE user_id = ? AND idempotency_key = ?`
+
+	order, err := scanOrder(getExecutor(ctx, o.db).QueryRowContext(ctx, query, userID, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domainerr.ErrOrderNotFound.Withf("no order with idempotency key %q", key)
+		}
+		return nil, fmt.Errorf("failed to get order by idempotency key: %w", err)
+	}
+
+	if err := o.loadItems(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// UpdateStatus implements repository.OrderRepository.
+// The update only applies while the order is still in fromStatus, so a
+// concurrent transition cannot be silently overwritten.
+func (o *orderRepository) UpdateStatus(ctx context.Context, id int, fromStatus string, toStatus string) error {
 	query := `
-		SELECT id, product_id, user_id, quantity, status, idempotency_key, created_at 
-		FROM orders 
-		WHERE idempotency_key = ?
+		UPDATE orders 
+		SET status = ? 
+		WHERE id = ? AND status = ?
 	`
 
-	var order entity.Order
-	err := o.db.QueryRowContext(ctx, query, key).Scan(
-		&order.ID,
-		&order.ProductID,
-		&order.UserID,
-		&order.Quantity,
-		&order.Status,
-		&order.IdempotencyKey,
-		&order.CreatedAt,
-	)
+	result, err := getExecutor(ctx, o.db).ExecContext(ctx, query, toStatus, id, fromStatus)
 	if err != nil {
-		return nil, err // Return sql.ErrNoRows as is for idempotency check
+		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
-	return &order, nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domainerr.ErrInvalidTransition.Withf("order %d is no longer %s", id, fromStatus)
+	}
+
+	return nil
+}
+
+// ListExpiredReservations implements repository.OrderRepository.
+// The lookup is served by idx_orders_status_reserved_until.
+func (o *orderRepository) ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	query := `
+		SELECT id FROM orders 
+		WHERE status = ? AND reserved_until IS NOT NULL AND reserved_until <= ? 
+		ORDER BY reserved_until, id 
+		LIMIT ?
+	`
+
+	rows, err := getExecutor(ctx, o.db).QueryContext(ctx, query, entity.OrderStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan order id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired reservations: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ListBackorders implements repository.OrderRepository.
+// Order IDs follow creation order, so ordering by ID gives the queue order.
+func (o *orderRepository) ListBackorders(ctx context.Context, productID int) ([]*entity.Order, error) {
+	query := `
+		SELECT ` + orderColumns + ` FROM orders 
+		WHERE status = ? AND id IN (SELECT order_id FROM order_items WHERE product_id = ?) 
+		ORDER BY id
+	`
+
+	rows, err := getExecutor(ctx, o.db).QueryContext(ctx, query, entity.OrderStatusBackordered, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backorders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*entity.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backorders: %w", err)
+	}
+
+	if err := o.loadItems(ctx, orders...); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// CountBackorders implements repository.OrderRepository.
+func (o *orderRepository) CountBackorders(ctx context.Context, productID int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM orders 
+		WHERE status = ? AND id IN (SELECT order_id FROM order_items WHERE product_id = ?)
+	`
+
+	var count int
+	if err := getExecutor(ctx, o.db).QueryRowContext(ctx, query, entity.OrderStatusBackordered, productID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count backorders: %w", err)
+	}
+	return count, nil
+}
+
+// SumUserQuantity implements repository.OrderRepository.
+// The user's orders are found through idx_orders_user_id.
+func (o *orderRepository) SumUserQuantity(ctx context.Context, userID string, productID int, since time.Time) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(oi.quantity), 0) 
+		FROM orders o 
+		JOIN order_items oi ON oi.order_id = o.id 
+		WHERE o.user_id = ? AND oi.product_id = ? AND o.status != ? AND o.created_at >= ?
+	`
+
+	var total int
+	if err := getExecutor(ctx, o.db).QueryRowContext(ctx, query, userID, productID, entity.OrderStatusCancelled, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum user order quantity: %w", err)
+	}
+	return total, nil
+}
+
+// PromoteBackorder implements repository.OrderRepository.
+// Like Create, it writes the header and the items separately, so callers run
+// it inside a transaction.
+func (o *orderRepository) PromoteBackorder(ctx context.Context, order *entity.Order) error {
+	query := `
+		UPDATE orders 
+		SET status = ?, reserved_until = ? 
+		WHERE id = ? AND status = ?
+	`
+
+	exec := getExecutor(ctx, o.db)
+	result, err := exec.ExecContext(ctx, query, entity.OrderStatusPending, order.ReservedUntil, order.ID, entity.OrderStatusBackordered)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domainerr.ErrInvalidTransition.Withf("order %d is no longer %s", order.ID, entity.OrderStatusBackordered)
+	}
+
+	for _, item := range order.Items {
+		if _, err := exec.ExecContext(ctx, `UPDATE order_items SET warehouse_id = ? WHERE id = ?`, nullID(item.WarehouseID), item.ID); err != nil {
+			return fmt.Errorf("failed to update order item: %w", err)
+		}
+	}
+
+	return nil
 }
 
 func NewOrderRepository(db *sql.DB) repository.OrderRepository {
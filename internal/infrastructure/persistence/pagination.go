@@ -0,0 +1,135 @@
+package persistence
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+)
+
+type columnKind int
+
+const (
+	kindInt columnKind = iota
+	kindString
+	kindTime
+)
+
+// sortColumn maps a public sort field onto a table column.
+type sortColumn struct {
+	column string
+	kind   columnKind
+}
+
+// cursor is the decoded form of the opaque pagination token. It records the
+// sort it was issued for together with the last row's sort value and id.
+type cursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    int             `json:"id"`
+}
+
+// keyset builds the ORDER BY and seek predicate for a sorted listing. Rows
+// are always tie-broken on id so that pages are stable.
+type keyset struct {
+	sort   string
+	column sortColumn
+	desc   bool
+}
+
+func newKeyset(sort, defaultSort string, columns map[string]sortColumn) (keyset, error) {
+	if sort == "" {
+		sort = defaultSort
+	}
+
+	field := strings.TrimPrefix(sort, "-")
+	column, ok := columns[field]
+	if !ok {
+		return keyset{}, domainerr.ErrInvalidSort.Withf("invalid sort field: %s", field)
+	}
+
+	return keyset{
+		sort:   sort,
+		column: column,
+		desc:   strings.HasPrefix(sort, "-"),
+	}, nil
+}
+
+func (k keyset) orderBy() string {
+	direction := "ASC"
+	if k.desc {
+		direction = "DESC"
+	}
+	if k.column.column == "id" {
+		return "id " + direction
+	}
+	return fmt.Sprintf("%s %s, id %s", k.column.column, direction, direction)
+}
+
+// seek returns the predicate selecting rows after the cursor position.
+func (k keyset) seek(token string) (string, []interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", nil, domainerr.ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != k.sort {
+		return "", nil, domainerr.ErrInvalidCursor
+	}
+
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+
+	if k.column.column == "id" {
+		return "id " + op + " ?", []interface{}{c.ID}, nil
+	}
+
+	value, err := k.decodeValue(c.Value)
+	if err != nil {
+		return "", nil, domainerr.ErrInvalidCursor
+	}
+
+	clause := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", k.column.column, op)
+	return clause, []interface{}{value, value, c.ID}, nil
+}
+
+func (k keyset) decodeValue(raw json.RawMessage) (interface{}, error) {
+	switch k.column.kind {
+	case kindInt:
+		var v int
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case kindTime:
+		var v time.Time
+		err := json.Unmarshal(raw, &v)
+		// Timestamps are stored in local time; bind in the same zone so the
+		// text comparison in SQLite lines up
+		return v.Local(), err
+	default:
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+// encodeCursor returns the token pointing just past a row with the given sort
+// value and id.
+func (k keyset) encodeCursor(value interface{}, id int) (string, error) {
+	v, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	raw, err := json.Marshal(cursor{Sort: k.sort, Value: v, ID: id})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -4,50 +4,148 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
 	"github.com/WaveCE29/product_order_system/internal/domain/entity"
 	"github.com/WaveCE29/product_order_system/internal/domain/repository"
 )
 
+const productColumns = `id, name, stock, reserved, price, currency, sku, description, category, barcode, reorder_threshold, allow_backorder, max_backorder_depth, ` +
+	`min_order_quantity, max_order_quantity, order_quantity_step, max_per_user, max_per_user_window_seconds, created_at, updated_at`
+
+var productSortColumns = map[string]sortColumn{
+	"id":         {column: "id", kind: kindInt},
+	"name":       {column: "name", kind: kindString},
+	"stock":      {column: "stock", kind: kindInt},
+	"available":  {column: "(stock - reserved)", kind: kindInt},
+	"price":      {column: "price", kind: kindInt},
+	"created_at": {column: "created_at", kind: kindTime},
+	"updated_at": {column: "updated_at", kind: kindTime},
+}
+
 type productRepository struct {
 	db *sql.DB
 }
 
+func scanProduct(row rowScanner) (*entity.Product, error) {
+	var (
+		product   entity.Product
+		sku       sql.NullString
+		barcode   sql.NullString
+		threshold sql.NullInt64
+		maxDepth  sql.NullInt64
+		rules     [5]sql.NullInt64
+	)
+	err := row.Scan(
+		&product.ID,
+		&product.Name,
+		&product.Stock,
+		&product.Reserved,
+		&product.Price,
+		&product.Currency,
+		&sku,
+		&product.Description,
+		&product.Category,
+		&barcode,
+		&threshold,
+		&product.AllowBackorder,
+		&maxDepth,
+		&rules[0],
+		&rules[1],
+		&rules[2],
+		&rules[3],
+		&rules[4],
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	product.SKU = sku.String
+	product.Barcode = barcode.String
+	product.ReorderThreshold = intPtr(threshold)
+	product.MaxBackorderDepth = intPtr(maxDepth)
+	product.MinOrderQuantity = intPtr(rules[0])
+	product.MaxOrderQuantity = intPtr(rules[1])
+	product.OrderQuantityStep = intPtr(rules[2])
+	product.MaxPerUser = intPtr(rules[3])
+	product.MaxPerUserWindowSeconds = intPtr(rules[4])
+	product.CalculateAvailable()
+	return &product, nil
+}
+
 func NewProductRepository(db *sql.DB) repository.ProductRepository {
 	return &productRepository{db: db}
 }
 
 // Create implements repository.ProductRepository.
+// Initial stock is placed in the primary warehouse and recorded in the ledger
+// as an opening balance.
 func (p *productRepository) Create(ctx context.Context, product *entity.Product) error {
 	query := `
-		INSERT INTO products (name, stock, created_at, updated_at) 
-		VALUES (?, ?, ?, ?)
+		INSERT INTO products (name, stock, price, currency, sku, description, category, barcode, reorder_threshold, allow_backorder, max_backorder_depth, 
+			min_order_quantity, max_order_quantity, order_quantity_step, max_per_user, max_per_user_window_seconds, created_at, updated_at) 
+		VALUES (?, 0, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := p.db.ExecContext(ctx, query,
-		product.Name,
-		product.Stock,
-		product.CreatedAt,
-		product.UpdatedAt)
-	if err != nil {
-		return fmt.Errorf("failed to create product: %w", err)
-	}
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		result, err := getExecutor(ctx, p.db).ExecContext(ctx, query,
+			product.Name,
+			product.Price,
+			product.Currency,
+			nullString(product.SKU),
+			product.Description,
+			product.Category,
+			nullString(product.Barcode),
+			nullInt(product.ReorderThreshold),
+			product.AllowBackorder,
+			nullInt(product.MaxBackorderDepth),
+			nullInt(product.MinOrderQuantity),
+			nullInt(product.MaxOrderQuantity),
+			nullInt(product.OrderQuantityStep),
+			nullInt(product.MaxPerUser),
+			nullInt(product.MaxPerUserWindowSeconds),
+			product.CreatedAt,
+			product.UpdatedAt)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domainerr.ErrDuplicateSKU.Withf("a product with SKU %q already exists", product.SKU)
+			}
+			return fmt.Errorf("failed to create product: %w", err)
+		}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
-	}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
 
-	product.ID = int(id)
-	return nil
+		product.ID = int(id)
+
+		warehouseID, err := primaryWarehouseID(ctx, p.db)
+		if err != nil {
+			return err
+		}
+
+		if _, err := p.applyStockDelta(ctx, product.ID, warehouseID, product.Stock, 0, repository.StockChange{
+			Reason: entity.StockReasonOpeningBalance,
+			Actor:  entity.ActorSystem,
+		}); err != nil {
+			return err
+		}
+		product.CalculateAvailable()
+		return nil
+	})
 }
 
 // GetAll implements repository.ProductRepository.
 func (p *productRepository) GetAll(ctx context.Context) ([]*entity.Product, error) {
-	query := `SELECT * FROM products ORDER BY created_at DESC`
+	query := `SELECT ` + productColumns + ` FROM products ORDER BY created_at DESC`
 
-	rows, err := p.db.QueryContext(ctx, query)
+	rows, err := getExecutor(ctx, p.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all products: %w", err)
 	}
@@ -57,18 +155,11 @@ func (p *productRepository) GetAll(ctx context.Context) ([]*entity.Product, erro
 	var products []*entity.Product
 
 	for rows.Next() {
-		var product entity.Product
-		err := rows.Scan(
-			&product.ID,
-			&product.Name,
-			&product.Stock,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
-		products = append(products, &product)
+		products = append(products, product)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -78,41 +169,257 @@ func (p *productRepository) GetAll(ctx context.Context) ([]*entity.Product, erro
 	return products, nil
 }
 
+// List implements repository.ProductRepository.
+func (p *productRepository) List(ctx context.Context, page repository.PageRequest) ([]*entity.Product, string, error) {
+	return p.list(ctx, "", page, "-created_at")
+}
+
+// ListLowStock implements repository.ProductRepository.
+// Products without a reorder threshold are never listed.
+func (p *productRepository) ListLowStock(ctx context.Context, page repository.PageRequest) ([]*entity.Product, string, error) {
+	return p.list(ctx, "reorder_threshold IS NOT NULL AND stock - reserved <= reorder_threshold", page, "available")
+}
+
+// list returns a keyset-paginated page of the products matching filter, which
+// may be empty.
+func (p *productRepository) list(ctx context.Context, filter string, page repository.PageRequest, defaultSort string) ([]*entity.Product, string, error) {
+	ks, err := newKeyset(page.Sort, defaultSort, productSortColumns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := `SELECT ` + productColumns + ` FROM products `
+	var (
+		args       []interface{}
+		conditions []string
+	)
+	if filter != "" {
+		conditions = append(conditions, filter)
+	}
+
+	if page.Cursor != "" {
+		clause, seekArgs, err := ks.seek(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, clause)
+		args = append(args, seekArgs...)
+	}
+
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ") + " "
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	limit := page.PageLimit()
+	query += "ORDER BY " + ks.orderBy() + " LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := getExecutor(ctx, p.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*entity.Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating products: %w", err)
+	}
+
+	var nextCursor string
+	if len(products) > limit {
+		products = products[:limit]
+		last := products[limit-1]
+		nextCursor, err = ks.encodeCursor(productSortValue(last, ks.column.column), last.ID)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return products, nextCursor, nil
+}
+
+func productSortValue(product *entity.Product, column string) interface{} {
+	switch column {
+	case "name":
+		return product.Name
+	case "stock":
+		return product.Stock
+	case "(stock - reserved)":
+		return product.Available
+	case "price":
+		return product.Price
+	case "created_at":
+		return product.CreatedAt
+	case "updated_at":
+		return product.UpdatedAt
+	default:
+		return product.ID
+	}
+}
+
 // GetbyID implements repository.ProductRepository.
 func (p *productRepository) GetbyID(ctx context.Context, id int) (*entity.Product, error) {
-	query := `SELECT id, name, stock, created_at, updated_at FROM products WHERE id = ?`
-	var product entity.Product
-	err := p.db.QueryRowContext(ctx, query, id).Scan(
-		&product.ID,
-		&product.Name,
-		&product.Stock,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = ?`
+	product, err := scanProduct(getExecutor(ctx, p.db).QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product with id %d not found", id)
+			return nil, domainerr.ErrProductNotFound.Withf("product with id %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
-	return &product, nil
+	return product, nil
+}
+
+// Search implements repository.ProductRepository.
+// Results are ranked by bm25 with name and SKU matches weighted above category
+// and description. Every term is matched as a prefix.
+func (p *productRepository) Search(ctx context.Context, query string, limit int, offset int) ([]*entity.ProductSearchResult, error) {
+	match := ftsMatchQuery(query)
+	if match == "" {
+		return nil, domainerr.Invalid("q", "invalid", "Search query must contain at least one letter or digit")
+	}
+
+	sqlQuery := `
+		SELECT ` + qualifiedColumns("p", productColumns) + `,
+			bm25(products_fts, 10.0, 10.0, 4.0, 1.0) AS rank,
+			snippet(products_fts, -1, '<mark>', '</mark>', '…', 12)
+		FROM products_fts
+		JOIN products p ON p.id = products_fts.rowid
+		WHERE products_fts MATCH ?
+		ORDER BY rank, p.id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := getExecutor(ctx, p.db).QueryContext(ctx, sqlQuery, match, limit, offset)
+	if err != nil {
+		if !p.searchIndexExists(ctx) {
+			return nil, domainerr.ErrSearchUnavailable.Withf("product search index is not available; the server must be built with FTS5 support")
+		}
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*entity.ProductSearchResult
+	for rows.Next() {
+		var (
+			result  entity.ProductSearchResult
+			rank    float64
+			snippet sql.NullString
+		)
+		product, err := scanProduct(scanFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &rank, &snippet)...)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Product = product
+		// bm25 is lower for better matches; flip it so higher scores rank first
+		result.Score = -rank
+		result.Snippet = snippet.String
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
+func (p *productRepository) searchIndexExists(ctx context.Context) bool {
+	var name string
+	err := getExecutor(ctx, p.db).QueryRowContext(ctx,
+		`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'products_fts'`).Scan(&name)
+	return err == nil
+}
+
+// ftsMatchQuery turns free text into an FTS5 query: each run of letters and
+// digits becomes a quoted prefix term, and all terms must match. Quoting keeps
+// FTS5 operators in user input from being interpreted.
+func ftsMatchQuery(text string) string {
+	terms := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// scanFunc adapts a function to rowScanner, for rows that carry extra columns
+// after an entity's own.
+type scanFunc func(dest ...interface{}) error
+
+func (f scanFunc) Scan(dest ...interface{}) error {
+	return f(dest...)
+}
+
+// qualifiedColumns prefixes each column in a comma-separated list with alias.
+func qualifiedColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, part := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GetBySKU implements repository.ProductRepository.
+// SKUs are matched case-insensitively, as enforced by idx_products_sku.
+func (p *productRepository) GetBySKU(ctx context.Context, sku string) (*entity.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE sku = ? COLLATE NOCASE`
+	product, err := scanProduct(getExecutor(ctx, p.db).QueryRowContext(ctx, query, sku))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domainerr.ErrProductNotFound.Withf("product with SKU %q not found", sku)
+		}
+		return nil, fmt.Errorf("failed to get product by SKU: %w", err)
+	}
+	return product, nil
 }
 
 // Update implements repository.ProductRepository.
+// Stock is not written here; it only changes through the ledgered stock
+// methods.
 func (p *productRepository) Update(ctx context.Context, product *entity.Product) error {
 	query := `
 		UPDATE products 
-		SET name = ?, stock = ?, updated_at = ? 
+		SET name = ?, price = ?, currency = ?, sku = ?, description = ?, category = ?, barcode = ?, reorder_threshold = ?, allow_backorder = ?, max_backorder_depth = ?, 
+			min_order_quantity = ?, max_order_quantity = ?, order_quantity_step = ?, max_per_user = ?, max_per_user_window_seconds = ?, updated_at = ? 
 		WHERE id = ?
 	`
 	product.UpdatedAt = time.Now()
 
-	result, err := p.db.ExecContext(ctx, query,
+	result, err := getExecutor(ctx, p.db).ExecContext(ctx, query,
 		product.Name,
-		product.Stock,
+		product.Price,
+		product.Currency,
+		nullString(product.SKU),
+		product.Description,
+		product.Category,
+		nullString(product.Barcode),
+		nullInt(product.ReorderThreshold),
+		product.AllowBackorder,
+		nullInt(product.MaxBackorderDepth),
+		nullInt(product.MinOrderQuantity),
+		nullInt(product.MaxOrderQuantity),
+		nullInt(product.OrderQuantityStep),
+		nullInt(product.MaxPerUser),
+		nullInt(product.MaxPerUserWindowSeconds),
 		product.UpdatedAt,
 		product.ID)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domainerr.ErrDuplicateSKU.Withf("a product with SKU %q already exists", product.SKU)
+		}
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
@@ -122,21 +429,265 @@ func (p *productRepository) Update(ctx context.Context, product *entity.Product)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("product with id %d not found", product.ID)
+		return domainerr.ErrProductNotFound.Withf("product with id %d not found", product.ID)
 	}
 
 	return nil
 
 }
 
-// UpdateStock implements repository.ProductRepository.
-func (p *productRepository) UpdateStock(ctx context.Context, productID int, newStock int) error {
+// Delete implements repository.ProductRepository.
+// Products still referenced by orders are kept so order history stays intact.
+func (p *productRepository) Delete(ctx context.Context, id int) error {
 	query := `
+		DELETE FROM products 
+		WHERE id = ? AND NOT EXISTS (SELECT 1 FROM order_items WHERE product_id = ?)
+	`
+
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		exec := getExecutor(ctx, p.db)
+
+		result, err := exec.ExecContext(ctx, query, id, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete product: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			if _, err := p.GetbyID(ctx, id); err != nil {
+				return err
+			}
+			return domainerr.ErrProductInUse.Withf("product with id %d is referenced by existing orders", id)
+		}
+
+		if _, err := exec.ExecContext(ctx, `DELETE FROM warehouse_stock WHERE product_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete warehouse stock: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// AdjustStock implements repository.ProductRepository.
+// The delta is applied relative to the stored stock, so concurrent
+// adjustments all take effect; stock held for pending orders cannot be
+// adjusted away. A zero delta returns a nil movement.
+func (p *productRepository) AdjustStock(ctx context.Context, productID int, warehouseID int, delta int, change repository.StockChange) (*entity.StockMovement, error) {
+	var movement *entity.StockMovement
+	err := withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		var err error
+		movement, err = p.applyStockDelta(ctx, productID, warehouseID, delta, 0, change)
+		if err != nil {
+			return fmt.Errorf("failed to adjust product stock: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// IncrementStock implements repository.ProductRepository.
+func (p *productRepository) IncrementStock(ctx context.Context, productID int, warehouseID int, quantity int, change repository.StockChange) error {
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		if _, err := p.applyStockDelta(ctx, productID, warehouseID, quantity, 0, change); err != nil {
+			return fmt.Errorf("failed to increment product stock: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReserveStock implements repository.ProductRepository.
+// The availability guard is part of the UPDATE itself so concurrent callers
+// can never reserve more than is available.
+func (p *productRepository) ReserveStock(ctx context.Context, productID int, warehouseID int, quantity int) error {
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		if err := p.applyReservedDelta(ctx, productID, warehouseID, quantity); err != nil {
+			return fmt.Errorf("failed to reserve product stock: %w", err)
+		}
+		return nil
+	})
+}
+
+// CommitReservedStock implements repository.ProductRepository.
+func (p *productRepository) CommitReservedStock(ctx context.Context, productID int, warehouseID int, quantity int, change repository.StockChange) error {
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		if _, err := p.applyStockDelta(ctx, productID, warehouseID, -quantity, -quantity, change); err != nil {
+			return fmt.Errorf("failed to commit reserved stock: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReleaseReservedStock implements repository.ProductRepository.
+func (p *productRepository) ReleaseReservedStock(ctx context.Context, productID int, warehouseID int, quantity int) error {
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		if err := p.applyReservedDelta(ctx, productID, warehouseID, -quantity); err != nil {
+			return fmt.Errorf("failed to release reserved stock: %w", err)
+		}
+		return nil
+	})
+}
+
+// TransferStock implements repository.ProductRepository.
+// Only available stock can be transferred; the product's total stock is
+// unchanged.
+func (p *productRepository) TransferStock(ctx context.Context, transfer *entity.StockTransfer) error {
+	return withinTransaction(ctx, p.db, func(ctx context.Context) error {
+		now := time.Now()
+
+		result, err := getExecutor(ctx, p.db).ExecContext(ctx, `
+			INSERT INTO stock_transfers (product_id, from_warehouse_id, to_warehouse_id, quantity, note, actor, created_at) 
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, transfer.ProductID, transfer.FromWarehouseID, transfer.ToWarehouseID, transfer.Quantity, nullString(transfer.Note), transfer.Actor, now)
+		if err != nil {
+			return fmt.Errorf("failed to record stock transfer: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		change := repository.StockChange{
+			Reason:      entity.StockReasonTransfer,
+			ReferenceID: strconv.FormatInt(id, 10),
+			Note:        transfer.Note,
+			Actor:       transfer.Actor,
+		}
+		if _, err := p.applyStockDelta(ctx, transfer.ProductID, transfer.FromWarehouseID, -transfer.Quantity, 0, change); err != nil {
+			return fmt.Errorf("failed to transfer product stock: %w", err)
+		}
+		if _, err := p.applyStockDelta(ctx, transfer.ProductID, transfer.ToWarehouseID, transfer.Quantity, 0, change); err != nil {
+			return fmt.Errorf("failed to transfer product stock: %w", err)
+		}
+
+		transfer.ID = int(id)
+		transfer.CreatedAt = now
+		return nil
+	})
+}
+
+// applyReservedDelta changes the quantity reserved in a warehouse and the
+// product's total in step. The warehouse can never reserve more than it has
+// in stock, nor release more than it holds.
+func (p *productRepository) applyReservedDelta(ctx context.Context, productID int, warehouseID int, reservedDelta int) error {
+	exec := getExecutor(ctx, p.db)
+
+	result, err := exec.ExecContext(ctx, `
+		UPDATE warehouse_stock 
+		SET reserved = reserved + ? 
+		WHERE warehouse_id = ? AND product_id = ? AND reserved + ? >= 0 AND stock >= reserved + ?
+	`, reservedDelta, warehouseID, productID, reservedDelta, reservedDelta)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		available, err := p.availableStock(ctx, productID, warehouseID)
+		if err != nil {
+			return err
+		}
+		if reservedDelta < 0 {
+			return fmt.Errorf("product %d holds less than %d reserved in warehouse %d", productID, -reservedDelta, warehouseID)
+		}
+		return domainerr.ErrInsufficientStock.Withf("insufficient stock for product %d in warehouse %d: available %d, requested %d", productID, warehouseID, available, reservedDelta)
+	}
+
+	return p.updateTotals(ctx, productID, 0, reservedDelta)
+}
+
+// applyStockDelta is the single place stock changes: it adds delta to the
+// product's stock in a warehouse and reservedDelta to the quantity reserved
+// there, refusing to leave less stock than is reserved, keeps the product's
+// totals in step and appends the matching stock movement. It must run inside
+// a transaction so the writes commit together. A zero delta records nothing.
+func (p *productRepository) applyStockDelta(ctx context.Context, productID int, warehouseID int, delta int, reservedDelta int, change repository.StockChange) (*entity.StockMovement, error) {
+	exec := getExecutor(ctx, p.db)
+	now := time.Now()
+
+	// A warehouse starts holding a product the first time its stock changes
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO warehouse_stock (warehouse_id, product_id) VALUES (?, ?) 
+		ON CONFLICT (warehouse_id, product_id) DO NOTHING
+	`, warehouseID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create warehouse stock: %w", err)
+	}
+
+	var balance int
+	err = exec.QueryRowContext(ctx, `
+		UPDATE warehouse_stock 
+		SET stock = stock + ?, reserved = reserved + ? 
+		WHERE warehouse_id = ? AND product_id = ? AND stock + ? >= reserved + ? AND reserved + ? >= 0
+		RETURNING stock
+	`, delta, reservedDelta, warehouseID, productID, delta, reservedDelta, reservedDelta).Scan(&balance)
+	if err == sql.ErrNoRows {
+		available, err := p.availableStock(ctx, productID, warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, domainerr.ErrInsufficientStock.Withf("insufficient stock for product %d in warehouse %d: available %d, requested %d", productID, warehouseID, available, -delta)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.updateTotals(ctx, productID, delta, reservedDelta); err != nil {
+		return nil, err
+	}
+
+	if delta == 0 {
+		return nil, nil
+	}
+
+	movement := &entity.StockMovement{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Delta:       delta,
+		Balance:     balance,
+		Reason:      change.Reason,
+		ReferenceID: change.ReferenceID,
+		Note:        change.Note,
+		Actor:       change.Actor,
+		CreatedAt:   now,
+	}
+
+	result, err := exec.ExecContext(ctx, `
+		INSERT INTO stock_movements (product_id, warehouse_id, delta, balance, reason, reference_id, note, actor, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, productID, warehouseID, delta, balance, change.Reason, nullString(change.ReferenceID), nullString(change.Note), change.Actor, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	movement.ID = int(id)
+	return movement, nil
+}
+
+// updateTotals keeps the product's stock and reserved totals in step with
+// its warehouse stock.
+func (p *productRepository) updateTotals(ctx context.Context, productID int, delta int, reservedDelta int) error {
+	result, err := getExecutor(ctx, p.db).ExecContext(ctx, `
 		UPDATE products 
-		SET stock = ?, updated_at = ? 
+		SET stock = stock + ?, reserved = reserved + ?, updated_at = ? 
 		WHERE id = ?
-	`
-	result, err := p.db.ExecContext(ctx, query, newStock, time.Now(), productID)
+	`, delta, reservedDelta, time.Now(), productID)
 	if err != nil {
 		return fmt.Errorf("failed to update product stock: %w", err)
 	}
@@ -147,9 +698,24 @@ func (p *productRepository) UpdateStock(ctx context.Context, productID int, newS
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("product with id %d not found", productID)
+		return domainerr.ErrProductNotFound.Withf("product with id %d not found", productID)
 	}
 
 	return nil
+}
 
+// availableStock returns the unreserved stock of a product in a warehouse.
+func (p *productRepository) availableStock(ctx context.Context, productID int, warehouseID int) (int, error) {
+	var available int
+	err := getExecutor(ctx, p.db).QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT stock - reserved FROM warehouse_stock WHERE warehouse_id = ? AND product_id = products.id), 0) 
+		FROM products WHERE id = ?
+	`, warehouseID, productID).Scan(&available)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domainerr.ErrProductNotFound.Withf("product with id %d not found", productID)
+		}
+		return 0, fmt.Errorf("failed to get product stock: %w", err)
+	}
+	return available, nil
 }
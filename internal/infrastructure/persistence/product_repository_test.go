@@ -0,0 +1,161 @@
+package persistence_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	"github.com/WaveCE29/product_order_system/internal/testenv"
+)
+
+func searchNames(t *testing.T, env *testenv.Env, query string) []string {
+	t.Helper()
+	results, err := env.Products.Search(context.Background(), query, 20, 0)
+	if err != nil {
+		t.Fatalf("search %q failed: %v", query, err)
+	}
+	names := make([]string, len(results))
+	for i, result := range results {
+		names[i] = result.Product.Name
+	}
+	return names
+}
+
+// TestSearchRanksAndTracksProducts checks that search ranks name matches
+// above description matches, marks the match in the snippet, and sees
+// products as they are after an update or a delete.
+func TestSearchRanksAndTracksProducts(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	create := func(req input.CreateProductRequest) *entity.Product {
+		t.Helper()
+		product, err := env.ProductUseCase.CreateProduct(ctx, req)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", req.Name, err)
+		}
+		return product
+	}
+	lamp := create(input.CreateProductRequest{Name: "Desk Lamp", Category: "lighting", Description: "Bright enough to read by, with a charging dock for your phone"})
+	create(input.CreateProductRequest{Name: "Phone Case", Category: "accessories", Description: "Slim silicone case"})
+	create(input.CreateProductRequest{Name: "Kettle", Category: "kitchen", Description: "Boils water"})
+
+	results, err := env.Products.Search(context.Background(), "pho", 20, 0)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("search for a prefix found %d products, want 2", len(results))
+	}
+	if results[0].Product.Name != "Phone Case" || results[1].Product.Name != "Desk Lamp" {
+		t.Errorf("search ranked %s before %s; a name match should rank first", results[0].Product.Name, results[1].Product.Name)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("scores %v and %v are not in descending order", results[0].Score, results[1].Score)
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>Phone</mark>") {
+		t.Errorf("snippet %q does not mark the match", results[0].Snippet)
+	}
+	if !strings.Contains(results[1].Snippet, "<mark>phone</mark>") {
+		t.Errorf("snippet %q does not come from the matching description", results[1].Snippet)
+	}
+
+	// Renaming and rewording the lamp replaces its old text in the index
+	if _, err := env.ProductUseCase.UpdateProduct(ctx, lamp.ID, input.UpdateProductRequest{Name: "Floor Lamp", Category: "lighting", Description: "Tall and warm"}); err != nil {
+		t.Fatalf("failed to update product: %v", err)
+	}
+	if names := searchNames(t, env, "phone"); len(names) != 1 || names[0] != "Phone Case" {
+		t.Errorf("search for the old description found %v, want [Phone Case]", names)
+	}
+	if names := searchNames(t, env, "desk"); len(names) != 0 {
+		t.Errorf("search for the old name found %v", names)
+	}
+	if names := searchNames(t, env, "floor lamp"); len(names) != 1 || names[0] != "Floor Lamp" {
+		t.Errorf("search for the new name found %v, want [Floor Lamp]", names)
+	}
+
+	// A deleted product leaves the index
+	if err := env.ProductUseCase.DeleteProduct(ctx, lamp.ID); err != nil {
+		t.Fatalf("failed to delete product: %v", err)
+	}
+	if names := searchNames(t, env, "lamp"); len(names) != 0 {
+		t.Errorf("search found deleted products %v", names)
+	}
+}
+
+// TestListPagesReturnEachProductOnce walks every page of the product list
+// under several sorts whose keys tie, and checks that each product appears
+// exactly once and in sort order.
+func TestListPagesReturnEachProductOnce(t *testing.T) {
+	env := testenv.New(t, testenv.Config{})
+	ctx := authz.AsSystem(context.Background())
+
+	prices := []int64{300, 100, 200, 100, 300, 100, 200}
+	for i, price := range prices {
+		// Names repeat too, so sorting by name also ties
+		name := fmt.Sprintf("Product %d", i%3)
+		if _, err := env.ProductUseCase.CreateProduct(ctx, input.CreateProductRequest{Name: name, Price: price, Stock: i}); err != nil {
+			t.Fatalf("failed to create product: %v", err)
+		}
+	}
+	// Every product created in the same instant ties on created_at
+	if _, err := env.DB.DB.ExecContext(ctx, `UPDATE products SET created_at = ?`, time.Now()); err != nil {
+		t.Fatalf("failed to align created_at: %v", err)
+	}
+
+	key := map[string]func(p *entity.Product) string{
+		"price":       func(p *entity.Product) string { return fmt.Sprintf("%06d", p.Price) },
+		"name":        func(p *entity.Product) string { return p.Name },
+		"-created_at": func(p *entity.Product) string { return p.CreatedAt.UTC().Format(time.RFC3339Nano) },
+		"-stock":      func(p *entity.Product) string { return fmt.Sprintf("%06d", p.Stock) },
+	}
+
+	for sort, keyOf := range key {
+		t.Run(sort, func(t *testing.T) {
+			descending := strings.HasPrefix(sort, "-")
+			seen := make(map[int]bool)
+			var previous *entity.Product
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > len(prices) {
+					t.Fatal("pagination did not end")
+				}
+				products, next, err := env.Products.List(ctx, repository.PageRequest{Limit: 2, Cursor: cursor, Sort: sort})
+				if err != nil {
+					t.Fatalf("failed to list page %d: %v", pages, err)
+				}
+				for _, product := range products {
+					if seen[product.ID] {
+						t.Errorf("product %d listed twice", product.ID)
+					}
+					seen[product.ID] = true
+					// Ties are broken by ID, in the same direction as the sort
+					if previous != nil {
+						a := fmt.Sprintf("%s/%06d", keyOf(previous), previous.ID)
+						b := fmt.Sprintf("%s/%06d", keyOf(product), product.ID)
+						if descending {
+							a, b = b, a
+						}
+						if a >= b {
+							t.Errorf("product %d (%s) listed after product %d (%s)", product.ID, keyOf(product), previous.ID, keyOf(previous))
+						}
+					}
+					previous = product
+				}
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if len(seen) != len(prices) {
+				t.Errorf("listed %d of %d products", len(seen), len(prices))
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+)
+
+type roleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) repository.RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// GetAll implements repository.RoleRepository.
+func (r *roleRepository) GetAll(ctx context.Context) ([]*entity.Role, error) {
+	query := `
+		SELECT r.name, r.description, rp.permission
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		ORDER BY r.name, rp.permission
+	`
+
+	rows, err := getExecutor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*entity.Role
+	for rows.Next() {
+		var (
+			name, description string
+			permission        sql.NullString
+		)
+		if err := rows.Scan(&name, &description, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, &entity.Role{Name: name, Description: description, Permissions: []string{}})
+		}
+		if permission.Valid {
+			role := roles[len(roles)-1]
+			role.Permissions = append(role.Permissions, permission.String)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetSubjectRoles implements repository.RoleRepository.
+func (r *roleRepository) GetSubjectRoles(ctx context.Context, subject string) ([]string, error) {
+	query := `SELECT role FROM user_roles WHERE subject = ? ORDER BY role`
+	return r.queryStrings(ctx, "roles", query, subject)
+}
+
+// GetPermissions implements repository.RoleRepository.
+func (r *roleRepository) GetPermissions(ctx context.Context, roles ...string) ([]string, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(roles))
+	for i, role := range roles {
+		args[i] = role
+	}
+	query := `SELECT DISTINCT permission FROM role_permissions WHERE role IN (?` + strings.Repeat(", ?", len(roles)-1) + `) ORDER BY permission`
+	return r.queryStrings(ctx, "permissions", query, args...)
+}
+
+func (r *roleRepository) queryStrings(ctx context.Context, what, query string, args ...interface{}) ([]string, error) {
+	rows, err := getExecutor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", what, err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate %s: %w", what, err)
+	}
+
+	return values, nil
+}
+
+// GetAssignments implements repository.RoleRepository.
+func (r *roleRepository) GetAssignments(ctx context.Context) ([]*entity.RoleAssignment, error) {
+	query := `SELECT subject, role, created_at FROM user_roles ORDER BY subject, role`
+
+	rows, err := getExecutor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []*entity.RoleAssignment
+	for rows.Next() {
+		var assignment entity.RoleAssignment
+		if err := rows.Scan(&assignment.Subject, &assignment.Role, &assignment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan role assignment: %w", err)
+		}
+		assignments = append(assignments, &assignment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate role assignments: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// Assign implements repository.RoleRepository. Foreign keys are not enforced,
+// so the role is checked to exist.
+func (r *roleRepository) Assign(ctx context.Context, assignment *entity.RoleAssignment) error {
+	query := `
+		INSERT INTO user_roles (subject, role, created_at)
+		SELECT ?, name, ? FROM roles WHERE name = ?
+		ON CONFLICT (subject, role) DO NOTHING
+	`
+
+	if _, err := getExecutor(ctx, r.db).ExecContext(ctx, query, assignment.Subject, assignment.CreatedAt, assignment.Role); err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	var exists bool
+	err := getExecutor(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM roles WHERE name = ?)`, assignment.Role).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check role: %w", err)
+	}
+	if !exists {
+		return domainerr.ErrRoleNotFound.Withf("role %q not found", assignment.Role)
+	}
+
+	return nil
+}
+
+// Unassign implements repository.RoleRepository.
+func (r *roleRepository) Unassign(ctx context.Context, subject, role string) error {
+	query := `DELETE FROM user_roles WHERE subject = ? AND role = ?`
+
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, query, subject, role)
+	if err != nil {
+		return fmt.Errorf("failed to unassign role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domainerr.ErrRoleNotFound.Withf("%s does not have role %q", subject, role)
+	}
+
+	return nil
+}
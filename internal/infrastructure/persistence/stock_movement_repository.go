@@ -0,0 +1,166 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+)
+
+const stockMovementColumns = `id, product_id, warehouse_id, delta, balance, reason, reference_id, note, actor, created_at`
+
+var stockMovementSortColumns = map[string]sortColumn{
+	"id": {column: "id", kind: kindInt},
+}
+
+type stockMovementRepository struct {
+	db *sql.DB
+}
+
+func scanStockMovement(row rowScanner) (*entity.StockMovement, error) {
+	var (
+		movement    entity.StockMovement
+		referenceID sql.NullString
+		note        sql.NullString
+	)
+	err := row.Scan(
+		&movement.ID,
+		&movement.ProductID,
+		&movement.WarehouseID,
+		&movement.Delta,
+		&movement.Balance,
+		&movement.Reason,
+		&referenceID,
+		&note,
+		&movement.Actor,
+		&movement.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	movement.ReferenceID = referenceID.String
+	movement.Note = note.String
+	return &movement, nil
+}
+
+// ListByProduct implements repository.StockMovementRepository.
+func (s *stockMovementRepository) ListByProduct(ctx context.Context, productID int, page repository.PageRequest) ([]*entity.StockMovement, string, error) {
+	ks, err := newKeyset(page.Sort, "-id", stockMovementSortColumns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := `SELECT ` + stockMovementColumns + ` FROM stock_movements WHERE product_id = ? `
+	args := []interface{}{productID}
+
+	if page.Cursor != "" {
+		clause, seekArgs, err := ks.seek(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query += "AND " + clause + " "
+		args = append(args, seekArgs...)
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	limit := page.PageLimit()
+	query += "ORDER BY " + ks.orderBy() + " LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := getExecutor(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []*entity.StockMovement
+	for rows.Next() {
+		movement, err := scanStockMovement(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating stock movements: %w", err)
+	}
+
+	var nextCursor string
+	if len(movements) > limit {
+		movements = movements[:limit]
+		last := movements[limit-1]
+		nextCursor, err = ks.encodeCursor(last.ID, last.ID)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return movements, nextCursor, nil
+}
+
+// Verify implements repository.StockMovementRepository.
+// Each warehouse's stock of a product must equal the sum of that warehouse's
+// movements and its latest balance; each product's total stock must equal
+// the sum of all its movements and of its warehouse stock.
+func (s *stockMovementRepository) Verify(ctx context.Context) ([]entity.StockDiscrepancy, error) {
+	query := `
+		SELECT product_id, warehouse_id, stock, ledger_sum, ledger_balance, NULL AS warehouse_total
+		FROM (
+			SELECT ws.product_id, ws.warehouse_id, ws.stock,
+				COALESCE((SELECT SUM(delta) FROM stock_movements m WHERE m.product_id = ws.product_id AND m.warehouse_id = ws.warehouse_id), 0) AS ledger_sum,
+				COALESCE((SELECT balance FROM stock_movements m WHERE m.product_id = ws.product_id AND m.warehouse_id = ws.warehouse_id ORDER BY id DESC LIMIT 1), 0) AS ledger_balance
+			FROM warehouse_stock ws
+		)
+		WHERE stock <> ledger_sum OR stock <> ledger_balance
+		UNION ALL
+		SELECT product_id, 0, stock, ledger_sum, NULL, warehouse_total
+		FROM (
+			SELECT p.id AS product_id, p.stock,
+				COALESCE((SELECT SUM(delta) FROM stock_movements m WHERE m.product_id = p.id), 0) AS ledger_sum,
+				COALESCE((SELECT SUM(stock) FROM warehouse_stock ws WHERE ws.product_id = p.id), 0) AS warehouse_total
+			FROM products p
+		)
+		WHERE stock <> ledger_sum OR stock <> warehouse_total
+		ORDER BY 1, 2
+	`
+
+	rows, err := getExecutor(ctx, s.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify stock ledger: %w", err)
+	}
+	defer rows.Close()
+
+	var discrepancies []entity.StockDiscrepancy
+	for rows.Next() {
+		var (
+			d              entity.StockDiscrepancy
+			ledgerBalance  sql.NullInt64
+			warehouseTotal sql.NullInt64
+		)
+		if err := rows.Scan(&d.ProductID, &d.WarehouseID, &d.Stock, &d.LedgerSum, &ledgerBalance, &warehouseTotal); err != nil {
+			return nil, fmt.Errorf("failed to scan stock discrepancy: %w", err)
+		}
+		if ledgerBalance.Valid {
+			balance := int(ledgerBalance.Int64)
+			d.LedgerBalance = &balance
+		}
+		if warehouseTotal.Valid {
+			total := int(warehouseTotal.Int64)
+			d.WarehouseTotal = &total
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+func NewStockMovementRepository(db *sql.DB) repository.StockMovementRepository {
+	return &stockMovementRepository{db: db}
+}
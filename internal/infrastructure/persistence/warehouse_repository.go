@@ -0,0 +1,165 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/WaveCE29/product_order_system/internal/domain/domainerr"
+	"github.com/WaveCE29/product_order_system/internal/domain/entity"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+)
+
+const warehouseColumns = `id, code, name, created_at, updated_at`
+
+type warehouseRepository struct {
+	db *sql.DB
+}
+
+func scanWarehouse(row rowScanner) (*entity.Warehouse, error) {
+	var warehouse entity.Warehouse
+	err := row.Scan(
+		&warehouse.ID,
+		&warehouse.Code,
+		&warehouse.Name,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// Create implements repository.WarehouseRepository.
+func (w *warehouseRepository) Create(ctx context.Context, warehouse *entity.Warehouse) error {
+	query := `
+		INSERT INTO warehouses (code, name, created_at, updated_at) 
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := getExecutor(ctx, w.db).ExecContext(ctx, query,
+		warehouse.Code,
+		warehouse.Name,
+		warehouse.CreatedAt,
+		warehouse.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domainerr.ErrDuplicateWarehouse.Withf("a warehouse with code %q already exists", warehouse.Code)
+		}
+		return fmt.Errorf("failed to create warehouse: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	warehouse.ID = int(id)
+	return nil
+}
+
+// GetByID implements repository.WarehouseRepository.
+func (w *warehouseRepository) GetByID(ctx context.Context, id int) (*entity.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE id = ?`
+
+	warehouse, err := scanWarehouse(getExecutor(ctx, w.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domainerr.ErrWarehouseNotFound.Withf("warehouse with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+// GetPrimary implements repository.WarehouseRepository.
+func (w *warehouseRepository) GetPrimary(ctx context.Context) (*entity.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses ORDER BY id LIMIT 1`
+
+	warehouse, err := scanWarehouse(getExecutor(ctx, w.db).QueryRowContext(ctx, query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domainerr.ErrWarehouseNotFound.Withf("no warehouse exists")
+		}
+		return nil, fmt.Errorf("failed to get primary warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+// GetAll implements repository.WarehouseRepository.
+func (w *warehouseRepository) GetAll(ctx context.Context) ([]*entity.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses ORDER BY id`
+
+	rows, err := getExecutor(ctx, w.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouses: %w", err)
+	}
+	defer rows.Close()
+
+	var warehouses []*entity.Warehouse
+	for rows.Next() {
+		warehouse, err := scanWarehouse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse: %w", err)
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+// ListStock implements repository.WarehouseRepository.
+func (w *warehouseRepository) ListStock(ctx context.Context, productID int) ([]*entity.WarehouseStock, error) {
+	query := `
+		SELECT w.id, w.code, COALESCE(ws.stock, 0), COALESCE(ws.reserved, 0)
+		FROM warehouses w
+		LEFT JOIN warehouse_stock ws ON ws.warehouse_id = w.id AND ws.product_id = ?
+		ORDER BY w.id
+	`
+
+	rows, err := getExecutor(ctx, w.db).QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list warehouse stock: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*entity.WarehouseStock
+	for rows.Next() {
+		level := &entity.WarehouseStock{ProductID: productID}
+		if err := rows.Scan(&level.WarehouseID, &level.WarehouseCode, &level.Stock, &level.Reserved); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse stock: %w", err)
+		}
+		level.Available = level.Stock - level.Reserved
+		levels = append(levels, level)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating warehouse stock: %w", err)
+	}
+
+	return levels, nil
+}
+
+// primaryWarehouseID returns the ID of the warehouse with the lowest ID.
+func primaryWarehouseID(ctx context.Context, db *sql.DB) (int, error) {
+	var id int
+	err := getExecutor(ctx, db).QueryRowContext(ctx, `SELECT id FROM warehouses ORDER BY id LIMIT 1`).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domainerr.ErrWarehouseNotFound.Withf("no warehouse exists")
+		}
+		return 0, fmt.Errorf("failed to get primary warehouse: %w", err)
+	}
+	return id, nil
+}
+
+func NewWarehouseRepository(db *sql.DB) repository.WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
@@ -0,0 +1,112 @@
+// Package testenv wires the application the way cmd/server does, on a fresh
+// migrated database, for tests that exercise the use cases or the HTTP layer
+// end to end.
+package testenv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WaveCE29/product_order_system/internal/application/authz"
+	"github.com/WaveCE29/product_order_system/internal/application/port/input"
+	"github.com/WaveCE29/product_order_system/internal/application/port/output"
+	"github.com/WaveCE29/product_order_system/internal/application/usecase"
+	"github.com/WaveCE29/product_order_system/internal/domain/allocation"
+	"github.com/WaveCE29/product_order_system/internal/domain/repository"
+	database "github.com/WaveCE29/product_order_system/internal/infrastructure/db"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/notification"
+	"github.com/WaveCE29/product_order_system/internal/infrastructure/persistence"
+	"github.com/WaveCE29/product_order_system/pkg/logger"
+)
+
+// Config overrides the defaults of an Env. Zero fields take the defaults: a
+// one hour reservation TTL, the first_fit strategy and a log notifier.
+type Config struct {
+	ReservationTTL time.Duration
+	Strategy       string
+	Notifier       output.StockAlertNotifier
+}
+
+// Env is the application built on one test database.
+type Env struct {
+	DB     *database.Database
+	Logger logger.Logger
+
+	Products    repository.ProductRepository
+	Orders      repository.OrderRepository
+	Warehouses  repository.WarehouseRepository
+	Movements   repository.StockMovementRepository
+	Roles       repository.RoleRepository
+	Idempotency repository.IdempotencyRepository
+	TxManager   repository.TransactionManager
+	Policy      authz.Policy
+
+	OrderUseCase     input.OrderUseCase
+	ProductUseCase   input.ProductUseCase
+	StockUseCase     input.StockUseCase
+	WarehouseUseCase input.WarehouseUseCase
+
+	// Alerts wraps the configured notifier as cmd/server does; Drain it to
+	// wait for alerts raised so far.
+	Alerts *notification.AsyncNotifier
+}
+
+// New migrates a database in the test's temporary directory and builds the
+// repositories and use cases on it. The database is closed when the test
+// ends.
+func New(t testing.TB, config Config) *Env {
+	t.Helper()
+
+	log := logger.NewNopLogger()
+
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "test.db"), log)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if config.ReservationTTL == 0 {
+		config.ReservationTTL = time.Hour
+	}
+	if config.Strategy == "" {
+		config.Strategy = allocation.FirstFit
+	}
+	if config.Notifier == nil {
+		config.Notifier = notification.NewLogNotifier(log)
+	}
+
+	allocator, err := allocation.NewStrategy(config.Strategy)
+	if err != nil {
+		t.Fatalf("failed to create allocation strategy: %v", err)
+	}
+
+	env := &Env{
+		DB:          db,
+		Logger:      log,
+		Products:    persistence.NewProductRepository(db.DB),
+		Orders:      persistence.NewOrderRepository(db.DB),
+		Warehouses:  persistence.NewWarehouseRepository(db.DB),
+		Movements:   persistence.NewStockMovementRepository(db.DB),
+		Roles:       persistence.NewRoleRepository(db.DB),
+		Idempotency: persistence.NewIdempotencyRepository(db.DB),
+		TxManager:   persistence.NewTransactionManager(db.DB),
+	}
+	env.Policy = authz.NewPolicy(env.Roles, log)
+	env.Alerts = notification.NewAsyncNotifier(config.Notifier, log)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := env.Alerts.Drain(ctx); err != nil {
+			t.Errorf("low-stock alerts still in flight: %v", err)
+		}
+	})
+
+	env.OrderUseCase = usecase.NewOrderUseCase(env.Orders, env.Products, env.Warehouses, env.TxManager, allocator, config.ReservationTTL, env.Alerts, env.Policy, log)
+	env.ProductUseCase = usecase.NewProductUseCase(env.Products, env.TxManager, env.Policy, log)
+	env.StockUseCase = usecase.NewStockUseCase(env.Products, env.Warehouses, env.Movements, env.TxManager, env.OrderUseCase, env.Alerts, env.Policy, log)
+	env.WarehouseUseCase = usecase.NewWarehouseUseCase(env.Warehouses, env.Policy, log)
+
+	return env
+}
@@ -47,6 +47,12 @@ func NewDevelopmentLogger() (Logger, error) {
 	}, nil
 }
 
+func NewNopLogger() Logger {
+	return &zapLogger{
+		logger: zap.NewNop().Sugar(),
+	}
+}
+
 func (l *zapLogger) Info(msg string, keysAndValues ...interface{}) {
 	l.logger.Infow(msg, keysAndValues...)
 }